@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// baseline is a set of errors that were known at some point, typically when
+// go-sumtype was adopted by a large code base, and that shouldn't fail a
+// check until they're fixed.
+//
+// Entries identify errors without positions, so that unrelated edits to a
+// file don't invalidate them: an error is identified by its package, the
+// function it's in, its rule, its sum type and its missing variants. For
+// errors without a sum type or variants, the message is used instead.
+type baseline []baselineEntry
+
+// baselineEntry is a single error recorded in a baseline file.
+type baselineEntry struct {
+	Package  string `json:"package"`
+	Function string `json:"function,omitempty"`
+	Rule     string `json:"rule"`
+	SumType  string `json:"sumType,omitempty"`
+	// The names of the missing variants, sorted, if any.
+	Variants []string `json:"variants,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func newBaselineEntry(r record) baselineEntry {
+	e := baselineEntry{
+		Package:  r.Package,
+		Function: r.Function,
+		Rule:     r.Rule,
+		SumType:  r.SumType,
+		Message:  r.Message,
+	}
+	for _, v := range r.Missing {
+		e.Variants = append(e.Variants, v.Name)
+	}
+	sort.Strings(e.Variants)
+	return e
+}
+
+func (e baselineEntry) String() string {
+	var where string
+	if e.Function == "" {
+		where = e.Package
+	} else {
+		where = e.Package + "." + e.Function
+	}
+	return fmt.Sprintf("%s: %s", where, e.Message)
+}
+
+// covers returns true if and only if this entry records the given error, or
+// an error that is at least as bad. That is, an error for the same sum type
+// in the same function is covered as long as it misses no variants that
+// weren't already missing.
+func (e baselineEntry) covers(o baselineEntry) bool {
+	if !e.related(o) {
+		return false
+	}
+	if len(e.Variants) == 0 && len(o.Variants) == 0 {
+		return e.Message == o.Message
+	}
+	missing := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		missing[v] = true
+	}
+	for _, v := range o.Variants {
+		if !missing[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// related returns true if and only if this entry and the given one are for
+// the same rule and sum type in the same function.
+func (e baselineEntry) related(o baselineEntry) bool {
+	return e.Package == o.Package && e.Function == o.Function &&
+		e.Rule == o.Rule && e.SumType == o.SumType
+}
+
+// equal returns true if and only if this entry records exactly the given
+// error.
+func (e baselineEntry) equal(o baselineEntry) bool {
+	return e.Message == o.Message && len(e.Variants) == len(o.Variants) && e.covers(o)
+}
+
+// readBaseline reads the baseline file at the given path.
+func readBaseline(filename string) (baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var base baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return base, nil
+}
+
+// writeBaseline writes a baseline file recording the given errors to the
+// given path. Entries are sorted so that the file changes as little as
+// possible when it's rewritten.
+func writeBaseline(filename string, records []record) error {
+	base := make(baseline, 0, len(records))
+	for _, r := range records {
+		base = append(base, newBaselineEntry(r))
+	}
+	sort.SliceStable(base, func(i, j int) bool {
+		return base.key(i) < base.key(j)
+	})
+	data, err := json.MarshalIndent(base, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0666)
+}
+
+// key returns a string by which entries are sorted.
+func (base baseline) key(i int) string {
+	e := base[i]
+	return strings.Join([]string{
+		e.Package, e.Function, e.Rule, e.SumType, e.Message,
+	}, "\x00")
+}
+
+// filter returns the given records without those covered by an entry in this
+// baseline, along with the entries that no longer cover any record. Each
+// entry covers at most one record, so that new errors of the same kind in the
+// same function are still reported.
+//
+// An error that got worse, i.e., one that is missing variants its entry
+// doesn't record, is reported, but its entry isn't considered fixed.
+func (base baseline) filter(records []record) ([]record, []baselineEntry) {
+	used := make([]bool, len(base))
+	matched := make([]bool, len(records))
+	// Prefer exact matches, so that an entry for an error isn't used up by
+	// another error it also covers.
+	matches := []func(b, e baselineEntry) bool{
+		baselineEntry.equal,
+		baselineEntry.covers,
+		baselineEntry.related,
+	}
+	for pass, match := range matches {
+		for i, r := range records {
+			if matched[i] {
+				continue
+			}
+			e := newBaselineEntry(r)
+			for j, b := range base {
+				if used[j] || !match(b, e) {
+					continue
+				}
+				used[j] = true
+				// Errors that only got worse are still reported.
+				matched[i] = pass < 2
+				break
+			}
+		}
+	}
+	var remaining []record
+	for i, r := range records {
+		if !matched[i] {
+			remaining = append(remaining, r)
+		}
+	}
+	var fixed []baselineEntry
+	for j, b := range base {
+		if !used[j] {
+			fixed = append(fixed, b)
+		}
+	}
+	return remaining, fixed
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// inexhaustiveRecord returns a record of an inexhaustive type switch over the
+// sum type T on the given line of the given function, missing the given
+// variants.
+func inexhaustiveRecord(function string, line int, missing ...string) record {
+	r := record{
+		Rule: "inexhaustive",
+		Message: fmt.Sprintf(
+			"exhaustiveness check failed for sum type 'T': missing cases for %s",
+			strings.Join(missing, ", ")),
+		Line:     line,
+		Package:  "example.com/m",
+		Function: function,
+		SumType:  "T",
+	}
+	for _, name := range missing {
+		r.Missing = append(r.Missing, variantRecord{Name: name})
+	}
+	return r
+}
+
+// TestBaselineFilter tests that records are only reported if no baseline
+// entry covers them, that entries are reported as fixed once they no longer
+// match any record and that each entry only covers a single record.
+func TestBaselineFilter(t *testing.T) {
+	unsealed := record{
+		Rule:    "unsealed",
+		Message: "interface 'U' is not sealed",
+		Line:    1,
+		Package: "example.com/m",
+	}
+	tests := []struct {
+		name    string
+		base    []record
+		records []record
+		// The lines of the records still reported.
+		remaining []int
+		// The messages of the entries reported as fixed.
+		fixed []string
+	}{
+		{
+			name:    "exact match",
+			base:    []record{inexhaustiveRecord("F", 1, "A", "B"), unsealed},
+			records: []record{inexhaustiveRecord("F", 10, "B", "A"), unsealed},
+		},
+		{
+			name:    "subset of missing variants",
+			base:    []record{inexhaustiveRecord("F", 1, "A", "B")},
+			records: []record{inexhaustiveRecord("F", 1, "A")},
+		},
+		{
+			name:      "worsened error",
+			base:      []record{inexhaustiveRecord("F", 1, "A")},
+			records:   []record{inexhaustiveRecord("F", 1, "A", "B")},
+			remaining: []int{1},
+		},
+		{
+			name: "fixed entry",
+			base: []record{
+				inexhaustiveRecord("F", 1, "A"),
+				inexhaustiveRecord("G", 2, "B"),
+			},
+			records: []record{inexhaustiveRecord("F", 1, "A")},
+			fixed: []string{
+				"exhaustiveness check failed for sum type 'T': missing cases for B",
+			},
+		},
+		{
+			name: "error in another function",
+			base: []record{inexhaustiveRecord("F", 1, "A")},
+			records: []record{
+				inexhaustiveRecord("G", 1, "A"),
+			},
+			remaining: []int{1},
+			fixed: []string{
+				"exhaustiveness check failed for sum type 'T': missing cases for A",
+			},
+		},
+		{
+			name: "two identical errors in one function",
+			base: []record{inexhaustiveRecord("F", 1, "A")},
+			records: []record{
+				inexhaustiveRecord("F", 1, "A"),
+				inexhaustiveRecord("F", 2, "A"),
+			},
+			remaining: []int{2},
+		},
+		{
+			name: "two identical errors in one function, both recorded",
+			base: []record{
+				inexhaustiveRecord("F", 1, "A"),
+				inexhaustiveRecord("F", 2, "A"),
+			},
+			records: []record{
+				inexhaustiveRecord("F", 3, "A"),
+				inexhaustiveRecord("F", 4, "A"),
+			},
+		},
+		{
+			name: "exact match preferred",
+			base: []record{
+				inexhaustiveRecord("F", 2, "A", "B"),
+				inexhaustiveRecord("F", 1, "A"),
+			},
+			// The first record is also covered by the first entry,
+			// which would leave only the second entry for the second
+			// record, which is worse, if exact matches weren't
+			// preferred.
+			records: []record{
+				inexhaustiveRecord("F", 1, "A"),
+				inexhaustiveRecord("F", 2, "A", "B"),
+			},
+		},
+	}
+	for _, test := range tests {
+		var base baseline
+		for _, r := range test.base {
+			base = append(base, newBaselineEntry(r))
+		}
+		remaining, fixed := base.filter(test.records)
+		var lines []int
+		for _, r := range remaining {
+			lines = append(lines, r.Line)
+		}
+		var msgs []string
+		for _, e := range fixed {
+			msgs = append(msgs, e.Message)
+		}
+		assert.Equal(t, test.remaining, lines, test.name)
+		assert.Equal(t, test.fixed, msgs, test.name)
+	}
+}
+
+// TestBaselineReadWrite tests that a baseline file records the given errors
+// sorted, with their missing variants sorted, and that it can be read back.
+func TestBaselineReadWrite(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "go-test-sumtype-baseline-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	path := filepath.Join(tmpdir, "baseline.json")
+
+	records := []record{
+		inexhaustiveRecord("G", 2, "B", "A"),
+		inexhaustiveRecord("F", 1, "C"),
+	}
+	if err := writeBaseline(path, records); err != nil {
+		t.Fatal(err)
+	}
+	base, err := readBaseline(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, baseline{newBaselineEntry(records[1]), newBaselineEntry(records[0])}, base)
+	assert.Equal(t, []string{"A", "B"}, base[1].Variants)
+}
@@ -18,10 +18,24 @@ MySumType must satisfy the following:
 
 go-sumtype will produce an error if any of the above is not true.
 
+A declaration may pin the intended set of variants by listing them after the
+type name:
+
+	//go-sumtype:decl MySumType VariantA VariantB
+
+Types implementing MySumType that aren't listed, and listed names that aren't
+variants, are then reported.
+
+Exported types in the declaring package that get the unexported method by
+embedding, through which other packages could implement MySumType, are
+reported too. With -embedding, types in other packages that implement
+MySumType by embedding are reported as well.
+
 For valid declarations, go-sumtype will look for all occurrences in which a
 value of type MySumType participates in a type switch statement. In those
 occurrences, it will attempt to detect whether the type switch is exhaustive
-or not. If it's not, go-sumtype will report an error. For example:
+or not. This includes type switches in other packages that import the package
+declaring MySumType. If it's not, go-sumtype will report an error. For example:
 
 	$ cat mysumtype.go
 	package main
@@ -52,6 +66,124 @@ Adding either a default clause or a clause to handle *VariantB will cause
 exhaustive checks to pass.
 
 As a special case, if the type switch statement contains a default clause
-that always panics, then exhaustiveness checks are still performed.
+that never completes normally, then exhaustiveness checks are still
+performed. A clause never completes normally when every path through it ends
+in a call that never returns, such as panic, os.Exit, log.Fatal, t.Fatal or a
+function whose doc comment contains a line like so:
+
+	//go-sumtype:noreturn
+
+Generic interfaces may be declared as sum types too. A type switch over an
+instantiation of a generic sum type must handle each of its variants
+instantiated with the same type arguments.
+
+Sets of named constants may be declared as enums:
+
+	//go-sumtype:enum Color
+
+Color must be a type whose underlying type is a boolean, numeric or string
+type. Its variants are all of the package level constants of type Color, and
+every expression switch statement whose tag has type Color is checked in the
+same way as type switches over interfaces.
+
+Chains of if/else-if statements whose conditions are all comma-ok type
+assertions on the same value are checked in the same way as type switches,
+with a final else clause playing the role of a default clause.
+
+A case clause whose type is an interface covers every variant whose values
+implement that interface. A variant A whose values aren't pointers isn't
+covered by an interface that only *A implements. If a variant is itself an interface, then it is covered
+either by a case clause for it or by case clauses for all of the concrete
+variants implementing it.
+
+If -assertions is given, then single-value type assertions on sum types
+outside of test files (e.g., x.(*VariantA), which panics for every other
+variant) are reported too.
+
+go-sumtype also reports case clauses in case analysis over a sum type that
+can never be selected: cases that match no variant, cases that duplicate an
+earlier case and cases whose variants are all matched by an earlier interface
+case.
+
+Case analysis annotated with //go-sumtype:ignore (on the line before it or at
+the end of its first line) isn't checked. An annotation of the form
+//go-sumtype:partial VariantC VariantD instead acknowledges that the listed
+variants are deliberately not handled, while still reporting any others.
+Annotations that have no effect are reported.
+
+Exhaustiveness errors in switch statements come with a suggested fix that
+inserts a case clause for each missing variant. Run go-sumtype with -fix to
+apply them. The body of each inserted clause is panic("TODO") by default,
+which can be changed with -fix-body, a text/template with the fields .SumType
+and .Variant.
+
+Test files are checked too, unless -test=false is given. Variants declared
+in test files only need to be handled in test files.
+
+The gen subcommand writes a file containing a type safe API for every sum
+type declared in a package, and is meant to be used with go generate:
+
+	//go:generate go-sumtype gen
+
+For a sum type T, the generated file contains a TVisitor interface with a
+Visit method for each variant, an AcceptT function and a MatchT function that
+takes a function for each variant. Adding a variant and regenerating the file
+breaks compilation of every incomplete visitor and every call to MatchT. With
+-json, MarshalT and UnmarshalT functions are generated too, which encode and
+decode a T as JSON with a discriminator field identifying its variant. A
+variant's discriminator defaults to its name, and can be changed with a
+//go-sumtype:tag line in its doc comment. Run `go-sumtype gen -h` for the
+other flags.
+
+The list subcommand prints every sum type declared in the given packages, with
+the location of its declaration, its variants (and whether they implement it
+with value or pointer receivers) and the number of switch statements over it.
+With -json, the list is printed as JSON. Sum types declared in the
+configuration file are listed too.
+
+The graph subcommand prints a diagram of the sum types declared in the given
+packages and their variants, in Graphviz DOT or (with -format mermaid) Mermaid
+syntax. Nested sum types are drawn as sub-hierarchies. With -fields, struct
+variants also have edges to the sum types their fields refer to, and -pkg and
+-root limit the diagram to some packages or to the sum types reachable from
+some roots.
+
+The lsp subcommand runs a Language Server Protocol server over stdin and
+stdout. It checks the packages containing the documents open in an editor,
+including unsaved changes, publishes the errors found as diagnostics and
+offers code actions that add missing case clauses.
+
+Sum types may also be declared by fully qualified name, e.g.,
+example.com/x/ast.Node, in a .go-sumtype.yaml, .go-sumtype.yml or
+.go-sumtype.toml file in the root of the main module (the module containing
+the working directory), or in the file given with -config. The configuration
+file may also enable -assertions for particular sum types and exclude paths
+in which no errors are reported. Invalid declarations in the configuration
+file are reported in the declaring package and in every package importing
+it. See the README for its format.
+
+The -format flag selects a machine readable output format: json, sarif,
+checkstyle or github (workflow commands that annotate pull requests). Each
+record includes a stable rule ID identifying the kind of error, e.g.,
+inexhaustive or unsealed, and the sum type and missing variants involved.
+
+To adopt go-sumtype in a code base with many existing errors, -write-baseline
+records them in a file. With -baseline, errors recorded in that file aren't
+reported unless they have gotten worse, e.g., a type switch is now missing
+more variants, and entries for errors that have been fixed are reported on
+stderr. Entries are keyed by package, function, sum type and missing variants
+rather than by line.
+
+With -watch, go-sumtype keeps running and prints the full list of errors again
+whenever the files of the given packages change. Only the changed packages are
+checked again, along with the packages importing them if the variants of a sum
+type declared in them changed. This uses inotify, and is only supported on
+Linux.
+
+The checker itself is implemented as an analysis pass in the
+github.com/BurntSushi/go-sumtype/sumtype package, so go-sumtype may also be
+used as a vet tool:
+
+	$ go vet -vettool=$(which go-sumtype) ./...
 */
 package main
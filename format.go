@@ -0,0 +1,549 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+// formats maps the name of every output format to the function that writes
+// records in that format.
+var formats = map[string]func(w io.Writer, records []record) error{
+	"text":       writeText,
+	"json":       writeJSON,
+	"sarif":      writeSARIF,
+	"checkstyle": writeCheckstyle,
+	"github":     writeGitHub,
+}
+
+// driverFlags are the flags that are only supported by checkWithDriver.
+var driverFlags = []string{"format", "baseline", "write-baseline", "watch"}
+
+// valueFlags are the flags taking a value, other than those of the analyzer,
+// that are supported by either checkWithDriver or the standard analysis
+// driver.
+var valueFlags = map[string]bool{
+	"format":         true,
+	"baseline":       true,
+	"write-baseline": true,
+	"c":              true,
+	"debug":          true,
+	"cpuprofile":     true,
+	"memprofile":     true,
+	"trace":          true,
+	"tags":           true,
+}
+
+// hasDriverFlag returns true if and only if the given command line arguments
+// include one of driverFlags. Without them, the standard analysis driver is
+// used.
+//
+// The values of flags given as separate arguments, e.g., `-config file`, are
+// skipped, so that the flags following them are found too.
+func hasDriverFlag(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return false
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		for _, flag := range driverFlags {
+			if name == flag {
+				return true
+			}
+		}
+		if !hasValue && takesValue(name) {
+			i++
+		}
+	}
+	return false
+}
+
+// takesValue returns true if and only if the flag with the given name takes
+// a value, which isn't given with the flag if it's a separate argument.
+func takesValue(name string) bool {
+	if f := sumtype.Analyzer.Flags.Lookup(name); f != nil {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		return !ok || !b.IsBoolFlag()
+	}
+	return valueFlags[name]
+}
+
+// checkWithDriver checks the packages named by the given command line
+// arguments and writes the errors found to stdout in the format given by the
+// -format flag.
+//
+// With -write-baseline, the errors found are written to a baseline file
+// instead. With -baseline, errors recorded in a baseline file aren't
+// reported, and baseline entries that no longer correspond to any error are
+// reported to stderr. (See baseline.)
+//
+// With -watch, the packages are checked again whenever their files change,
+// and the errors found are written again each time. (See watcher.)
+//
+// Like the standard analysis driver, it exits with status 3 if any errors
+// were found and 1 if the packages could not be checked.
+func checkWithDriver(args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("go-sumtype: ")
+
+	flags := flag.NewFlagSet("go-sumtype", flag.ExitOnError)
+	format := flags.String("format", "text",
+		"output format: text, json, sarif, checkstyle or github")
+	tests := flags.Bool("test", true, "indicates whether test files should be analyzed, too")
+	baselineFile := flags.String("baseline", "",
+		"only report errors that aren't recorded in this baseline file")
+	writeBaselineFile := flags.String("write-baseline", "",
+		"record the errors found in this baseline file instead of reporting them")
+	watch := flags.Bool("watch", false,
+		"check packages again whenever their files change")
+	sumtype.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	flags.Parse(args)
+	write, ok := formats[*format]
+	if !ok {
+		log.Fatalf("unknown output format '%s'", *format)
+	}
+
+	conf := &packages.Config{Mode: packages.LoadAllSyntax, Tests: *tests}
+	pkgs, err := packages.Load(conf, flags.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+	records, err := check(pkgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *writeBaselineFile != "" {
+		if err := writeBaseline(*writeBaselineFile, records); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	var base baseline
+	if *baselineFile != "" {
+		if base, err = readBaseline(*baselineFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	report := func(records []record) int {
+		return reportRecords(write, base, records)
+	}
+	if *watch {
+		report(records)
+		log.Fatal(newWatcher(conf, pkgs, records).run(report))
+	}
+	if report(records) > 0 {
+		os.Exit(3)
+	}
+}
+
+// reportRecords writes the given records to stdout with the given function,
+// except for those covered by the given baseline (which may be nil). Entries
+// in the baseline that no longer cover any record are reported to stderr. It
+// returns the number of records written.
+func reportRecords(
+	write func(w io.Writer, records []record) error,
+	base baseline,
+	records []record,
+) int {
+	records, fixed := base.filter(records)
+	for _, e := range fixed {
+		fmt.Fprintf(os.Stderr, "go-sumtype: fixed since baseline: %s\n", e)
+	}
+	if err := write(os.Stdout, records); err != nil {
+		log.Fatal(err)
+	}
+	return len(records)
+}
+
+// check runs the sum type analyzer on the given packages and returns a
+// record for each error found, sorted by position. Errors in files shared by
+// a package and its test variant are only included once.
+func check(pkgs []*packages.Package) ([]record, error) {
+	graph, err := checker.Analyze([]*analysis.Analyzer{sumtype.Analyzer}, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		file         string
+		line, column int
+		message      string
+	}
+	seen := make(map[key]bool)
+	var records []record
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, act.Err
+		}
+		for _, err := range act.Result.([]error) {
+			f, ok := sumtype.NewFinding(err)
+			if !ok {
+				continue
+			}
+			r := newRecord(act.Package.Fset, f)
+			r.Package = act.Package.PkgPath
+			r.Function = enclosingFunc(act.Package.Syntax, f.Pos)
+			k := key{r.File, r.Line, r.Column, r.Message}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			records = append(records, r)
+		}
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// sortRecords sorts the given records by position.
+func sortRecords(records []record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		ri, rj := records[i], records[j]
+		if ri.File != rj.File {
+			return ri.File < rj.File
+		}
+		if ri.Line != rj.Line {
+			return ri.Line < rj.Line
+		}
+		return ri.Column < rj.Column
+	})
+}
+
+// record is a machine readable description of an error found by the sum
+// type analyzer. It is also the format of each error in JSON output.
+type record struct {
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	// The import path of the package and the name of the function, if any,
+	// in which the error was found. Methods are named like (*T).M.
+	Package  string `json:"package"`
+	Function string `json:"function,omitempty"`
+	SumType  string `json:"sumType,omitempty"`
+	// The variants that aren't handled, if any.
+	Missing []variantRecord `json:"missing,omitempty"`
+}
+
+// variantRecord describes a variant of a sum type and where it is declared.
+// The position is absent if it isn't known.
+type variantRecord struct {
+	Name   string `json:"name"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+func newRecord(fset *token.FileSet, f sumtype.Finding) record {
+	pos := fset.Position(f.Pos)
+	end := pos
+	if f.End.IsValid() {
+		end = fset.Position(f.End)
+	}
+	r := record{
+		Rule:      f.Rule,
+		Message:   f.Message,
+		File:      relPath(pos.Filename),
+		Line:      pos.Line,
+		Column:    pos.Column,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+		SumType:   f.SumType,
+	}
+	for _, v := range f.Variants {
+		vr := variantRecord{Name: v.Name()}
+		if v.Pos().IsValid() {
+			vpos := fset.Position(v.Pos())
+			vr.File = relPath(vpos.Filename)
+			vr.Line = vpos.Line
+			vr.Column = vpos.Column
+		}
+		r.Missing = append(r.Missing, vr)
+	}
+	return r
+}
+
+// enclosingFunc returns the name of the function or method declared in the
+// given files that contains the given position, or an empty string if there
+// is no such function.
+func enclosingFunc(files []*ast.File, pos token.Pos) string {
+	for _, file := range files {
+		if pos < file.Pos() || pos > file.End() {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || pos < fn.Pos() || pos > fn.End() {
+				continue
+			}
+			if fn.Recv == nil || len(fn.Recv.List) == 0 {
+				return fn.Name.Name
+			}
+			return fmt.Sprintf("(%s).%s", types.ExprString(recvBase(fn.Recv.List[0].Type)), fn.Name.Name)
+		}
+	}
+	return ""
+}
+
+// recvBase returns the given receiver type without its type arguments.
+func recvBase(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: recvBase(e.X)}
+	case *ast.ParenExpr:
+		return recvBase(e.X)
+	case *ast.IndexExpr:
+		return e.X
+	case *ast.IndexListExpr:
+		return e.X
+	}
+	return expr
+}
+
+// relPath returns the given path relative to the current directory, if it's
+// in the current directory. Otherwise, it's returned as is.
+func relPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+func writeText(w io.Writer, records []record) error {
+	for _, r := range records {
+		_, err := fmt.Fprintf(w, "%s:%d:%d: %s\n", r.File, r.Line, r.Column, r.Message)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, records []record) error {
+	if records == nil {
+		records = []record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(records)
+}
+
+// writeSARIF writes records as a SARIF 2.1.0 log, as consumed by code
+// scanning tools.
+func writeSARIF(w io.Writer, records []record) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+	type artifactLocation struct {
+		URI string `json:"uri"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation artifactLocation `json:"artifactLocation"`
+		Region           region           `json:"region"`
+	}
+	type location struct {
+		ID               *int             `json:"id,omitempty"`
+		Message          *message         `json:"message,omitempty"`
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type properties struct {
+		SumType string   `json:"sumType,omitempty"`
+		Missing []string `json:"missing,omitempty"`
+	}
+	type result struct {
+		RuleID           string     `json:"ruleId"`
+		Level            string     `json:"level"`
+		Message          message    `json:"message"`
+		Locations        []location `json:"locations"`
+		RelatedLocations []location `json:"relatedLocations,omitempty"`
+		Properties       properties `json:"properties"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+	type driver struct {
+		Name           string `json:"name"`
+		InformationURI string `json:"informationUri"`
+		Rules          []rule `json:"rules"`
+	}
+	type tool struct {
+		Driver driver `json:"driver"`
+	}
+	type run struct {
+		Tool    tool     `json:"tool"`
+		Results []result `json:"results"`
+	}
+	type log struct {
+		Version string `json:"version"`
+		Schema  string `json:"$schema"`
+		Runs    []run  `json:"runs"`
+	}
+
+	var rules []rule
+	for id, desc := range sumtype.Rules {
+		rules = append(rules, rule{ID: id, ShortDescription: message{desc}})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	results := []result{}
+	for _, r := range records {
+		res := result{
+			RuleID:  r.Rule,
+			Level:   "error",
+			Message: message{r.Message},
+			Locations: []location{{PhysicalLocation: physicalLocation{
+				ArtifactLocation: artifactLocation{filepath.ToSlash(r.File)},
+				Region: region{
+					StartLine:   r.Line,
+					StartColumn: r.Column,
+					EndLine:     r.EndLine,
+					EndColumn:   r.EndColumn,
+				},
+			}}},
+			Properties: properties{SumType: r.SumType},
+		}
+		for i, v := range r.Missing {
+			res.Properties.Missing = append(res.Properties.Missing, v.Name)
+			if v.File == "" {
+				continue
+			}
+			id := i
+			res.RelatedLocations = append(res.RelatedLocations, location{
+				ID:      &id,
+				Message: &message{"variant " + v.Name},
+				PhysicalLocation: physicalLocation{
+					ArtifactLocation: artifactLocation{filepath.ToSlash(v.File)},
+					Region:           region{StartLine: v.Line, StartColumn: v.Column},
+				},
+			})
+		}
+		results = append(results, res)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(log{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []run{{
+			Tool: tool{Driver: driver{
+				Name:           "go-sumtype",
+				InformationURI: sumtype.Analyzer.URL,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// writeCheckstyle writes records in the XML format of Checkstyle, which is
+// understood by many CI systems.
+func writeCheckstyle(w io.Writer, records []record) error {
+	type checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+	type checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	type checkstyle struct {
+		XMLName xml.Name          `xml:"checkstyle"`
+		Version string            `xml:"version,attr"`
+		Files   []*checkstyleFile `xml:"file"`
+	}
+
+	out := checkstyle{Version: "5.0"}
+	files := make(map[string]*checkstyleFile)
+	for _, r := range records {
+		f, ok := files[r.File]
+		if !ok {
+			f = &checkstyleFile{Name: r.File}
+			files[r.File] = f
+			out.Files = append(out.Files, f)
+		}
+		f.Errors = append(f.Errors, checkstyleError{
+			Line:     r.Line,
+			Column:   r.Column,
+			Severity: "error",
+			Message:  r.Message,
+			Source:   "go-sumtype." + r.Rule,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHub writes records as GitHub Actions workflow commands, which
+// annotate the corresponding lines of pull requests.
+func writeGitHub(w io.Writer, records []record) error {
+	for _, r := range records {
+		_, err := fmt.Fprintf(w,
+			"::error file=%s,line=%d,col=%d,endLine=%d,endColumn=%d,title=%s::%s\n",
+			escapeGitHubProperty(filepath.ToSlash(r.File)),
+			r.Line, r.Column, r.EndLine, r.EndColumn,
+			escapeGitHubProperty("go-sumtype ("+r.Rule+")"),
+			escapeGitHubData(r.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C",
+	).Replace(s)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+// TestHasDriverFlag tests that flags only supported by checkWithDriver are
+// found, including after flags whose values are separate arguments, but not
+// after the first argument that isn't a flag.
+func TestHasDriverFlag(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"./...", false},
+		{"-format json ./...", true},
+		{"--format=json ./...", true},
+		{"-config .go-sumtype.yaml -format json ./...", true},
+		{"-config=.go-sumtype.yaml -format json ./...", true},
+		{"-c 2 -baseline base.json ./...", true},
+		{"-assertions -watch ./...", true},
+		{"-config .go-sumtype.yaml ./...", false},
+		{"-fix -diff ./...", false},
+		{"./... -format json", false},
+		{"-- -format json", false},
+	}
+	for _, test := range tests {
+		got := hasDriverFlag(strings.Fields(test.args))
+		assert.Equal(t, test.want, got, test.args)
+	}
+}
+
+// formatRecords are the records written by the tests of the output formats.
+// The second one has characters that need escaping in some formats.
+var formatRecords = []record{
+	{
+		Rule:      "inexhaustive",
+		Message:   "exhaustiveness check failed for sum type 'T': missing cases for A, B",
+		File:      "a/a.go",
+		Line:      3,
+		Column:    2,
+		EndLine:   3,
+		EndColumn: 20,
+		Package:   "example.com/m/a",
+		Function:  "F",
+		SumType:   "T",
+		Missing: []variantRecord{
+			{Name: "A", File: "a/t.go", Line: 7, Column: 6},
+			{Name: "B"},
+		},
+	},
+	{
+		Rule:      "unsealed",
+		Message:   "interface 'U' is not sealed\n<100% \"sure\">",
+		File:      "b/x:y,z.go",
+		Line:      1,
+		Column:    1,
+		EndLine:   1,
+		EndColumn: 9,
+		Package:   "example.com/m/b",
+	},
+}
+
+// TestWriteSARIF tests the SARIF output format. Every rule is described, but
+// since they change as rules are added, they're only compared with
+// sumtype.Rules rather than with the expected output.
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, formatRecords); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	rules := make(map[string]string)
+	for _, r := range out.Runs[0].Tool.Driver.Rules {
+		rules[r.ID] = r.ShortDescription.Text
+	}
+	assert.Equal(t, sumtype.Rules, rules)
+
+	var log map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	driver := log["runs"].([]any)[0].(map[string]any)["tool"].(map[string]any)["driver"]
+	delete(driver.(map[string]any), "rules")
+	got, err := json.Marshal(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{
+	"version": "2.1.0",
+	"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+	"runs": [{
+		"tool": {
+			"driver": {
+				"name": "go-sumtype",
+				"informationUri": "https://github.com/BurntSushi/go-sumtype"
+			}
+		},
+		"results": [
+			{
+				"ruleId": "inexhaustive",
+				"level": "error",
+				"message": {"text": "exhaustiveness check failed for sum type 'T': missing cases for A, B"},
+				"locations": [{
+					"physicalLocation": {
+						"artifactLocation": {"uri": "a/a.go"},
+						"region": {"startLine": 3, "startColumn": 2, "endLine": 3, "endColumn": 20}
+					}
+				}],
+				"relatedLocations": [{
+					"id": 0,
+					"message": {"text": "variant A"},
+					"physicalLocation": {
+						"artifactLocation": {"uri": "a/t.go"},
+						"region": {"startLine": 7, "startColumn": 6}
+					}
+				}],
+				"properties": {"sumType": "T", "missing": ["A", "B"]}
+			},
+			{
+				"ruleId": "unsealed",
+				"level": "error",
+				"message": {"text": "interface 'U' is not sealed\n<100% \"sure\">"},
+				"locations": [{
+					"physicalLocation": {
+						"artifactLocation": {"uri": "b/x:y,z.go"},
+						"region": {"startLine": 1, "startColumn": 1, "endLine": 1, "endColumn": 9}
+					}
+				}],
+				"properties": {}
+			}
+		]
+	}]
+}`, string(got))
+}
+
+// TestWriteCheckstyle tests the Checkstyle output format, in which errors
+// are grouped by file and messages are escaped as XML attributes.
+func TestWriteCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCheckstyle(&buf, formatRecords); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+	<file name="a/a.go">
+		<error line="3" column="2" severity="error" message="exhaustiveness check failed for sum type &#39;T&#39;: missing cases for A, B" source="go-sumtype.inexhaustive"></error>
+	</file>
+	<file name="b/x:y,z.go">
+		<error line="1" column="1" severity="error" message="interface &#39;U&#39; is not sealed&#xA;&lt;100% &#34;sure&#34;&gt;" source="go-sumtype.unsealed"></error>
+	</file>
+</checkstyle>
+`, buf.String())
+}
+
+// TestWriteGitHub tests the GitHub Actions output format, in which the
+// properties and messages of workflow commands are escaped.
+func TestWriteGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeGitHub(&buf, formatRecords); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "::error file=a/a.go,line=3,col=2,endLine=3,endColumn=20,"+
+		"title=go-sumtype (inexhaustive)::"+
+		"exhaustiveness check failed for sum type 'T': missing cases for A, B\n"+
+		"::error file=b/x%3Ay%2Cz.go,line=1,col=1,endLine=1,endColumn=9,"+
+		"title=go-sumtype (unsealed)::"+
+		"interface 'U' is not sealed%0A<100%25 \"sure\">\n", buf.String())
+}
+
+// TestEscapeGitHub tests the escaping of the properties and messages of
+// GitHub Actions workflow commands.
+func TestEscapeGitHub(t *testing.T) {
+	tests := []struct {
+		in, property, data string
+	}{
+		{"a/b.go", "a/b.go", "a/b.go"},
+		{"a:b,c", "a%3Ab%2Cc", "a:b,c"},
+		{"100%", "100%25", "100%25"},
+		{"a\r\nb\nc", "a%0D%0Ab%0Ac", "a%0D%0Ab%0Ac"},
+		{"%3A", "%253A", "%253A"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.property, escapeGitHubProperty(test.in), test.in)
+		assert.Equal(t, test.data, escapeGitHubData(test.in), test.in)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+const genUsage = `Usage: go-sumtype gen [flags] [package]
+
+gen writes a file to the directory of the given package (or the package in
+the current directory) containing a type safe visitor API for every sum type
+declared in the package. It is suitable for use with go generate:
+
+	//go:generate go-sumtype gen
+
+Flags:
+`
+
+// gen implements the `go-sumtype gen` command.
+func gen(args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("go-sumtype gen: ")
+
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), genUsage)
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "sumtype_gen.go",
+		"name of the generated file, relative to the package's directory")
+	types := flags.String("types", "",
+		"comma separated list of sum types to generate code for (default all)")
+	jsonFuncs := flags.Bool("json", false,
+		"also generate functions that encode and decode sum types as JSON")
+	jsonField := flags.String("json-field", "type",
+		"name of the JSON field identifying the variant of a sum type")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	pattern := "."
+	if flags.NArg() == 1 {
+		pattern = flags.Arg(0)
+	}
+
+	pkg, err := loadForGen(pattern, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := &sumtype.GenerateOptions{JSON: *jsonFuncs, JSONField: *jsonField}
+	if *types != "" {
+		opts.Types = strings.Split(*types, ",")
+	}
+	src, err := sumtype.Generate(pkg, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(pkgDir(pkg), *output)
+	if err := ioutil.WriteFile(path, src, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadForGen loads the single package matching the given pattern, ignoring
+// the contents of any previously generated file with the given name. (The
+// previously generated code may no longer compile, e.g., if a variant was
+// removed.)
+//
+// Type errors in the package are ignored, since code calling the previously
+// generated functions no longer compiles without them. Only the sum type
+// declarations and the package's scope are needed to generate code anyway.
+func loadForGen(pattern, output string) (*packages.Package, error) {
+	conf := &packages.Config{Mode: packages.NeedName | packages.NeedFiles}
+	pkgs, err := packages.Load(conf, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s matched %d packages, expected 1", pattern, len(pkgs))
+	}
+	// Dependencies are loaded from source rather than from export data,
+	// since getting export data for the package itself means compiling it,
+	// which fails on the type errors that are tolerated below.
+	conf.Mode = packages.LoadAllSyntax
+	path := filepath.Join(pkgDir(pkgs[0]), output)
+	old, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+	if err == nil {
+		conf.Overlay = map[string][]byte{
+			path: []byte("package " + old.Name.Name + "\n"),
+		}
+	}
+	if pkgs, err = packages.Load(conf, pattern); err != nil {
+		return nil, err
+	}
+	failed := false
+	for _, err := range pkgs[0].Errors {
+		if err.Kind != packages.TypeError {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		return nil, fmt.Errorf("errors loading %s", pattern)
+	}
+	return pkgs[0], nil
+}
+
+// pkgDir returns the directory containing the given package's files.
+func pkgDir(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return "."
+	}
+	return filepath.Dir(pkg.GoFiles[0])
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+// TestLoadForGenRegenerate tests that code can be generated again after a
+// variant is added, even though the package calls the previously generated
+// functions, which no longer compile while the generated file is ignored.
+func TestLoadForGenRegenerate(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "go-test-sumtype-gen-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	// Like go generate, gen is run from the package's directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpdir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	writeFile := func(name, code string) {
+		path := filepath.Join(tmpdir, name)
+		if err := os.WriteFile(path, []byte(code), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generate := func() string {
+		pkg, err := loadForGen(".", "sumtype_gen.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := sumtype.Generate(pkg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile("sumtype_gen.go", string(src))
+		return string(src)
+	}
+	writeFile("go.mod", "module example.com/m\n\ngo 1.22\n")
+	const shape = `
+package shape
+
+//go-sumtype:decl Shape
+
+type Shape interface{ sealed() }
+
+type Circle struct{}
+
+func (Circle) sealed() {}
+`
+	writeFile("shape.go", shape)
+	generate()
+	writeFile("use.go", `
+package shape
+
+func Name(s Shape) string {
+	return MatchShape(s, func(Circle) string { return "circle" })
+}
+`)
+
+	writeFile("shape.go", shape+"\ntype Square struct{}\n\nfunc (Square) sealed() {}\n")
+	src := generate()
+	assert.Contains(t, src, "onSquare func(Square) R")
+}
@@ -1,15 +1,17 @@
 module github.com/BurntSushi/go-sumtype
 
-go 1.17
+go 1.22.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/stretchr/testify v1.3.0
-	golang.org/x/tools v0.21.0
+	golang.org/x/mod v0.23.0
+	golang.org/x/tools v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/mod v0.17.0 // indirect
-	golang.org/x/sync v0.7.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
 )
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+const graphUsage = `Usage: go-sumtype graph [flags] [packages]
+
+graph prints a diagram of the sum types declared in the given packages (or
+./...) in Graphviz DOT or Mermaid syntax. Each sum type has an edge to each of
+its variants, and nested sum types are drawn as sub-hierarchies.
+
+For example, to render the sum types of a package as an SVG image:
+
+	go-sumtype graph ./ast | dot -Tsvg > ast.svg
+
+Flags:
+`
+
+// graphFormats maps the name of every graph format to the function that
+// writes a graph in that format.
+var graphFormats = map[string]func(w io.Writer, g *sumtype.Graph) error{
+	"dot":     writeDOT,
+	"mermaid": writeMermaid,
+}
+
+// graph implements the `go-sumtype graph` command.
+func graph(args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("go-sumtype graph: ")
+
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), graphUsage)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "dot", "output format: dot or mermaid")
+	pkgPaths := flags.String("pkg", "",
+		"comma separated list of import paths of packages whose sum types are drawn (default all)")
+	roots := flags.String("root", "",
+		"comma separated list of sum types to draw, along with those reachable from them (default all)")
+	fields := flags.Bool("fields", false,
+		"draw an edge from each struct variant to the sum types its fields refer to")
+	addConfigFlag(flags)
+	flags.Parse(args)
+	write, ok := graphFormats[*format]
+	if !ok {
+		log.Fatalf("unknown format '%s'", *format)
+	}
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	conf := &packages.Config{Mode: packages.LoadAllSyntax}
+	pkgs, err := packages.Load(conf, patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+	opts := &sumtype.GraphOptions{Fields: *fields}
+	if *pkgPaths != "" {
+		opts.Packages = strings.Split(*pkgPaths, ",")
+	}
+	if *roots != "" {
+		opts.Roots = strings.Split(*roots, ",")
+	}
+	g, err := sumtype.NewGraph(pkgs, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := write(os.Stdout, g); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeDOT writes the given graph in Graphviz DOT syntax. Sum types are drawn
+// as ellipses and variants as boxes. Edges for fields are dashed and labeled
+// with the field's name.
+func writeDOT(w io.Writer, g *sumtype.Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph sumtypes {\n")
+	fmt.Fprintf(bw, "\trankdir=LR;\n")
+	fmt.Fprintf(bw, "\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := "label=" + strconv.Quote(n.Label)
+		if n.SumType {
+			attrs += ", shape=ellipse"
+		}
+		fmt.Fprintf(bw, "\t%s [%s];\n", strconv.Quote(n.ID), attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "\t%s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		if e.Field != "" {
+			fmt.Fprintf(bw, " [style=dashed, label=%s]", strconv.Quote(e.Field))
+		}
+		fmt.Fprintf(bw, ";\n")
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+// writeMermaid writes the given graph as a Mermaid flowchart. Sum types are
+// drawn as stadiums and variants as rectangles. Edges for fields are dotted
+// and labeled with the field's name.
+func writeMermaid(w io.Writer, g *sumtype.Graph) error {
+	bw := bufio.NewWriter(w)
+	// Mermaid node IDs may only contain a limited set of characters, so
+	// nodes are numbered instead.
+	ids := make(map[string]string)
+	fmt.Fprintf(bw, "flowchart LR\n")
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+		if n.SumType {
+			fmt.Fprintf(bw, "\t%s([%s])\n", id, mermaidQuote(n.Label))
+		} else {
+			fmt.Fprintf(bw, "\t%s[%s]\n", id, mermaidQuote(n.Label))
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Field != "" {
+			fmt.Fprintf(bw, "\t%s -.->|%s| %s\n", ids[e.From], mermaidQuote(e.Field), ids[e.To])
+		} else {
+			fmt.Fprintf(bw, "\t%s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	return bw.Flush()
+}
+
+// mermaidQuote returns the given text as a quoted Mermaid string, in which
+// characters like brackets and asterisks aren't interpreted.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
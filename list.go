@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+const listUsage = `Usage: go-sumtype list [flags] [packages]
+
+list prints every sum type declared in the given packages (or ./...), or in
+the configuration file, along with the location of its declaration, its
+variants and the number of switch statements over it in the given packages.
+
+Flags:
+`
+
+// list implements the `go-sumtype list` command.
+func list(args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("go-sumtype list: ")
+
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), listUsage)
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "print the sum types as JSON")
+	addConfigFlag(flags)
+	flags.Parse(args)
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	conf := &packages.Config{Mode: packages.LoadAllSyntax}
+	pkgs, err := packages.Load(conf, patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+	sts, err := sumtype.List(pkgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *asJSON {
+		err = writeListJSON(os.Stdout, sts)
+	} else {
+		err = writeListText(os.Stdout, sts)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addConfigFlag adds the analyzer's -config flag to the given flags, so that
+// subcommands use the same configuration file as checking does.
+func addConfigFlag(flags *flag.FlagSet) {
+	f := sumtype.Analyzer.Flags.Lookup("config")
+	flags.Var(f.Value, f.Name, f.Usage)
+}
+
+// sumTypeRecord is the JSON description of a sum type printed by list.
+type sumTypeRecord struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+	Enum    bool   `json:"enum"`
+	// The position of the sum type's declaration.
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Switches int    `json:"switches"`
+	// The variants of the sum type, in the order in which they're declared.
+	Variants []listVariantRecord `json:"variants"`
+}
+
+// listVariantRecord is the JSON description of a variant printed by list.
+type listVariantRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Receiver string `json:"receiver,omitempty"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func writeListJSON(w io.Writer, sts []sumtype.SumType) error {
+	records := []sumTypeRecord{}
+	for _, st := range sts {
+		r := sumTypeRecord{
+			Package:  st.Package,
+			Name:     st.Name,
+			Enum:     st.Enum,
+			File:     relPath(st.Decl.Filename),
+			Line:     st.Decl.Line,
+			Column:   st.Decl.Column,
+			Switches: st.Switches,
+			Variants: []listVariantRecord{},
+		}
+		for _, v := range st.Variants {
+			r.Variants = append(r.Variants, listVariantRecord{
+				Name:     v.Name,
+				Type:     v.Type,
+				Receiver: v.Receiver,
+				File:     relPath(v.Pos.Filename),
+				Line:     v.Pos.Line,
+				Column:   v.Pos.Column,
+			})
+		}
+		records = append(records, r)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(records)
+}
+
+// writeListText writes each sum type on its own line, followed by an
+// indented line for each of its variants.
+func writeListText(w io.Writer, sts []sumtype.SumType) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, st := range sts {
+		kind := "sum type"
+		if st.Enum {
+			kind = "enum"
+		}
+		fmt.Fprintf(tw, "%s.%s\t%s\t%s (switches: %d)\n",
+			st.Package, st.Name, kind, position(st.Decl), st.Switches)
+		for _, v := range st.Variants {
+			fmt.Fprintf(tw, "    %s\t%s\t%s\n", v.Type, v.Receiver, position(v.Pos))
+		}
+	}
+	return tw.Flush()
+}
+
+// position returns the given position as file:line:column, with the file
+// relative to the current directory if possible.
+func position(pos token.Position) string {
+	pos.Filename = relPath(pos.Filename)
+	return pos.String()
+}
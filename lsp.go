@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+const lspUsage = `Usage: go-sumtype lsp [flags]
+
+lsp runs a Language Server Protocol server over stdin and stdout. The server
+checks the packages containing the documents open in an editor, including any
+unsaved changes, and publishes the errors found as diagnostics. Errors for
+inexhaustive switch statements come with code actions that add the missing
+case clauses.
+
+Flags:
+`
+
+// lsp implements the `go-sumtype lsp` command.
+func lsp(args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("go-sumtype lsp: ")
+
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), lspUsage)
+		flags.PrintDefaults()
+	}
+	tests := flags.Bool("test", true, "indicates whether test files should be analyzed, too")
+	sumtype.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := newLSPServer(*tests).serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// lspServer is a Language Server Protocol server that publishes the errors
+// found by the sum type analyzer as diagnostics.
+//
+// Only the subset of the protocol needed for diagnostics and code actions is
+// supported. Documents are synchronized in full on every change.
+type lspServer struct {
+	tests bool
+	w     io.Writer
+	// The contents of open documents, keyed by filename. They are given to
+	// the package loader as overlays.
+	overlay map[string][]byte
+	// The packages containing the open documents, as of the last check.
+	pkgs []*packages.Package
+	// The directories whose packages are in pkgs and up to date.
+	loaded map[string]bool
+	// The diagnostics most recently published, along with the code actions
+	// for each, keyed by filename.
+	diags map[string][]lspFileDiagnostic
+}
+
+func newLSPServer(tests bool) *lspServer {
+	return &lspServer{
+		tests:   tests,
+		overlay: make(map[string][]byte),
+		loaded:  make(map[string]bool),
+		diags:   make(map[string][]lspFileDiagnostic),
+	}
+}
+
+// serve reads requests and notifications from r and writes responses and
+// notifications to w until an exit notification is received or r is
+// exhausted.
+func (s *lspServer) serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	br := bufio.NewReader(r)
+	for {
+		msg, err := readLSPMessage(br)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a single request or notification. An error is only
+// returned if a response or notification couldn't be written.
+func (s *lspServer) handle(msg *lspMessage) error {
+	switch msg.Method {
+	case "initialize":
+		return s.reply(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					// Full synchronization.
+					"change": 1,
+					"save":   true,
+				},
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "go-sumtype"},
+		})
+	case "shutdown":
+		return s.reply(msg.ID, nil)
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.logf("didOpen: %s", err)
+		}
+		filename := uriToPath(params.TextDocument.URI)
+		text := []byte(params.TextDocument.Text)
+		s.overlay[filename] = text
+		// Opening a document only changes the packages to check if it has
+		// unsaved changes.
+		if disk, err := os.ReadFile(filename); err != nil || !bytes.Equal(disk, text) {
+			s.invalidate()
+		}
+		return s.refresh()
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.logf("didChange: %s", err)
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil
+		}
+		filename := uriToPath(params.TextDocument.URI)
+		last := params.ContentChanges[len(params.ContentChanges)-1]
+		s.overlay[filename] = []byte(last.Text)
+		s.invalidate()
+		return s.refresh()
+	case "textDocument/didSave":
+		return s.refresh()
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.logf("didClose: %s", err)
+		}
+		filename := uriToPath(params.TextDocument.URI)
+		text := s.overlay[filename]
+		delete(s.overlay, filename)
+		if disk, err := os.ReadFile(filename); err != nil || !bytes.Equal(disk, text) {
+			s.invalidate()
+		}
+		return s.refresh()
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			Range lspRange `json:"range"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.replyError(msg.ID, -32602, err.Error())
+		}
+		actions := []lspCodeAction{}
+		for _, d := range s.diags[uriToPath(params.TextDocument.URI)] {
+			if d.Range.overlaps(params.Range) {
+				actions = append(actions, d.actions...)
+			}
+		}
+		return s.reply(msg.ID, actions)
+	}
+	if msg.ID != nil {
+		return s.replyError(msg.ID, -32601, "method not supported: "+msg.Method)
+	}
+	return nil
+}
+
+// invalidate records that the packages containing the open documents need to
+// be loaded and checked again.
+func (s *lspServer) invalidate() {
+	s.loaded = make(map[string]bool)
+}
+
+// refresh loads and checks the packages containing the open documents, unless
+// they are up to date, and publishes the diagnostics found.
+//
+// All of the packages are checked whenever any document changes, since a
+// change to one package (e.g., adding a variant to a sum type) can cause
+// errors in others.
+func (s *lspServer) refresh() error {
+	dirs := make(map[string]string)
+	for filename := range s.overlay {
+		dirs[filepath.Dir(filename)] = filename
+	}
+	upToDate := len(dirs) == len(s.loaded)
+	for dir := range dirs {
+		upToDate = upToDate && s.loaded[dir]
+	}
+	if upToDate {
+		return nil
+	}
+
+	var patterns []string
+	for _, filename := range dirs {
+		patterns = append(patterns, "file="+filename)
+	}
+	sort.Strings(patterns)
+	s.pkgs = nil
+	s.loaded = make(map[string]bool)
+	if len(patterns) > 0 {
+		conf := &packages.Config{
+			Mode:    packages.LoadAllSyntax,
+			Tests:   s.tests,
+			Overlay: s.overlay,
+		}
+		pkgs, err := packages.Load(conf, patterns...)
+		if err != nil {
+			return s.logf("%s", err)
+		}
+		s.pkgs = pkgs
+		for dir := range dirs {
+			s.loaded[dir] = true
+		}
+	}
+	diags, err := s.check()
+	if err != nil {
+		return s.logf("%s", err)
+	}
+	for filename := range s.diags {
+		if _, ok := diags[filename]; !ok {
+			diags[filename] = nil
+		}
+	}
+	var filenames []string
+	for filename := range diags {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err := s.publish(filename, diags[filename]); err != nil {
+			return err
+		}
+	}
+	s.diags = diags
+	return nil
+}
+
+// check runs the sum type analyzer on the loaded packages and returns the
+// diagnostics found, keyed by filename. Every file in a package that could
+// be checked has an entry, even if there are no diagnostics for it. Files in
+// packages that couldn't be checked, e.g., because of type errors, keep
+// their previous diagnostics.
+func (s *lspServer) check() (map[string][]lspFileDiagnostic, error) {
+	diags := make(map[string][]lspFileDiagnostic)
+	if len(s.pkgs) == 0 {
+		return diags, nil
+	}
+	graph, err := checker.Analyze([]*analysis.Analyzer{sumtype.Analyzer}, s.pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string][]byte)
+	type key struct {
+		rng     lspRange
+		message string
+	}
+	seen := make(map[string]map[key]bool)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			for _, filename := range act.Package.CompiledGoFiles {
+				diags[filename] = s.diags[filename]
+			}
+			continue
+		}
+		for _, filename := range act.Package.CompiledGoFiles {
+			if _, ok := diags[filename]; !ok {
+				diags[filename] = []lspFileDiagnostic{}
+			}
+		}
+		fset := act.Package.Fset
+		for _, d := range act.Diagnostics {
+			filename := fset.Position(d.Pos).Filename
+			fd := lspFileDiagnostic{lspDiagnostic: lspDiagnostic{
+				Range:    s.lspRange(fset, contents, d.Pos, d.End),
+				Severity: 2,
+				Code:     d.Category,
+				Source:   "go-sumtype",
+				Message:  d.Message,
+			}}
+			k := key{fd.Range, fd.Message}
+			if seen[filename] == nil {
+				seen[filename] = make(map[key]bool)
+			}
+			if seen[filename][k] {
+				continue
+			}
+			seen[filename][k] = true
+			for _, fix := range d.SuggestedFixes {
+				edit := &lspWorkspaceEdit{Changes: make(map[string][]lspTextEdit)}
+				for _, te := range fix.TextEdits {
+					uri := pathToURI(fset.Position(te.Pos).Filename)
+					edit.Changes[uri] = append(edit.Changes[uri], lspTextEdit{
+						Range:   s.lspRange(fset, contents, te.Pos, te.End),
+						NewText: string(te.NewText),
+					})
+				}
+				fd.actions = append(fd.actions, lspCodeAction{
+					Title:       fix.Message,
+					Kind:        "quickfix",
+					Diagnostics: []lspDiagnostic{fd.lspDiagnostic},
+					Edit:        edit,
+				})
+			}
+			diags[filename] = append(diags[filename], fd)
+		}
+	}
+	return diags, nil
+}
+
+// lspRange converts the given range of positions to a range in the Language
+// Server Protocol, whose columns are in UTF-16 code units. The contents of
+// files are read as needed and cached in contents.
+func (s *lspServer) lspRange(
+	fset *token.FileSet,
+	contents map[string][]byte,
+	pos, end token.Pos,
+) lspRange {
+	if !end.IsValid() {
+		end = pos
+	}
+	start, stop := fset.Position(pos), fset.Position(end)
+	content, ok := contents[start.Filename]
+	if !ok {
+		if text, ok := s.overlay[start.Filename]; ok {
+			content = text
+		} else {
+			content, _ = os.ReadFile(start.Filename)
+		}
+		contents[start.Filename] = content
+	}
+	return lspRange{
+		Start: lspPositionOf(content, start),
+		End:   lspPositionOf(content, stop),
+	}
+}
+
+// lspPositionOf converts the given position in a file with the given
+// contents to a zero based position whose column is in UTF-16 code units.
+func lspPositionOf(content []byte, pos token.Position) lspPosition {
+	lineStart := pos.Offset - (pos.Column - 1)
+	if lineStart < 0 || pos.Offset > len(content) {
+		return lspPosition{Line: pos.Line - 1, Character: pos.Column - 1}
+	}
+	prefix := []rune(string(content[lineStart:pos.Offset]))
+	return lspPosition{Line: pos.Line - 1, Character: len(utf16.Encode(prefix))}
+}
+
+func (s *lspServer) publish(filename string, diags []lspFileDiagnostic) error {
+	params := struct {
+		URI         string          `json:"uri"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}{URI: pathToURI(filename), Diagnostics: []lspDiagnostic{}}
+	for _, d := range diags {
+		params.Diagnostics = append(params.Diagnostics, d.lspDiagnostic)
+	}
+	return s.notify("textDocument/publishDiagnostics", params)
+}
+
+// logf sends a message to the client to be logged, since the server keeps
+// running after errors such as packages that couldn't be loaded.
+func (s *lspServer) logf(format string, args ...interface{}) error {
+	return s.notify("window/logMessage", map[string]interface{}{
+		// Error.
+		"type":    1,
+		"message": fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *lspServer) reply(id *json.RawMessage, result interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *lspServer) replyError(id *json.RawMessage, code int, message string) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]interface{}{"code": code, "message": message},
+	})
+}
+
+func (s *lspServer) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *lspServer) write(msg interface{}) error {
+	return writeLSPMessage(s.w, msg)
+}
+
+// lspMessage is a JSON-RPC 2.0 request, notification or response.
+type lspMessage struct {
+	// The ID of a request or response, absent for notifications.
+	ID     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method,omitempty"`
+	Params json.RawMessage  `json:"params,omitempty"`
+	Result json.RawMessage  `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// readLSPMessage reads a single message, which is preceded by a header with
+// its length.
+func readLSPMessage(r *bufio.Reader) (*lspMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || (err == io.ErrUnexpectedEOF && len(header) == 0) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %s", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	msg := &lspMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeLSPMessage writes a single message, preceded by a header with its
+// length.
+func writeLSPMessage(w io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// uriToPath returns the path of the file with the given file:// URI.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathToURI returns the file:// URI of the file with the given path.
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// lspPosition is a zero based position in a document, whose column is in
+// UTF-16 code units.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+func (p lspPosition) before(q lspPosition) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Character < q.Character)
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// overlaps returns true if and only if the given ranges have any positions
+// in common, treating both as closed ranges.
+func (r lspRange) overlaps(o lspRange) bool {
+	return !r.End.before(o.Start) && !o.End.before(r.Start)
+}
+
+type lspDiagnostic struct {
+	Range lspRange `json:"range"`
+	// 1 for errors, 2 for warnings.
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// lspFileDiagnostic is a published diagnostic along with the code actions
+// that fix it.
+type lspFileDiagnostic struct {
+	lspDiagnostic
+	actions []lspCodeAction
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspWorkspaceEdit struct {
+	// Edits keyed by document URI.
+	Changes map[string][]lspTextEdit `json:"changes"`
+}
+
+type lspCodeAction struct {
+	Title       string            `json:"title"`
+	Kind        string            `json:"kind"`
+	Diagnostics []lspDiagnostic   `json:"diagnostics"`
+	Edit        *lspWorkspaceEdit `json:"edit"`
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lspTestSource = `package main
+
+//go-sumtype:decl T
+
+type T interface{ sealed() }
+
+type A struct{}
+
+func (*A) sealed() {}
+
+type B struct{}
+
+func (*B) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	}
+}
+`
+
+// lspClient is a scripted Language Server Protocol client for testing.
+type lspClient struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+}
+
+func (c *lspClient) notify(method string, params interface{}) {
+	err := writeLSPMessage(c.w, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request and returns the result of its response, skipping any
+// notifications received before it.
+func (c *lspClient) call(method string, params interface{}, result interface{}) {
+	c.nextID++
+	err := writeLSPMessage(c.w, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg.ID == nil {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %s", method, msg.Error.Message)
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+}
+
+// diagnostics waits for diagnostics to be published for the given file and
+// returns them.
+func (c *lspClient) diagnostics(uri string) []lspDiagnostic {
+	for {
+		msg := c.read()
+		if msg.Method == "window/logMessage" {
+			c.t.Fatalf("server error: %s", msg.Params)
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params struct {
+			URI         string          `json:"uri"`
+			Diagnostics []lspDiagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+		if params.URI == uri {
+			return params.Diagnostics
+		}
+	}
+}
+
+func (c *lspClient) read() *lspMessage {
+	msg, err := readLSPMessage(c.r)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// TestLSP tests that the server publishes diagnostics for open documents,
+// including unsaved changes, and offers code actions that fix them.
+func TestLSP(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "go-test-sumtype-lsp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	files := map[string]string{
+		"go.mod":  "module example.com/m\n\ngo 1.22\n",
+		"main.go": lspTestSource,
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(tmpdir, name), []byte(code), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- newLSPServer(true).serve(serverR, serverW)
+		serverW.Close()
+	}()
+	c := &lspClient{t: t, w: clientW, r: bufio.NewReader(clientR)}
+
+	var init struct {
+		Capabilities struct {
+			CodeActionProvider bool `json:"codeActionProvider"`
+		} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{"rootUri": pathToURI(tmpdir)}, &init)
+	assert.True(t, init.Capabilities.CodeActionProvider)
+	c.notify("initialized", map[string]interface{}{})
+
+	uri := pathToURI(filepath.Join(tmpdir, "main.go"))
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        uri,
+			"languageId": "go",
+			"version":    1,
+			"text":       lspTestSource,
+		},
+	})
+	diags := c.diagnostics(uri)
+	if !assert.Len(t, diags, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "inexhaustive", diags[0].Code)
+	assert.Equal(t, lspPosition{Line: 15, Character: 1}, diags[0].Range.Start)
+	assert.Contains(t, diags[0].Message, "missing cases for B")
+
+	var actions []lspCodeAction
+	c.call("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"range":        diags[0].Range,
+		"context":      map[string]interface{}{"diagnostics": diags},
+	}, &actions)
+	if assert.Len(t, actions, 1) {
+		edits := actions[0].Edit.Changes[uri]
+		if assert.Len(t, edits, 1) {
+			assert.Contains(t, edits[0].NewText, "case *B:")
+		}
+	}
+
+	// An unsaved change that handles B fixes the error.
+	fixed := strings.Replace(lspTestSource, "case *A:", "case *A, *B:", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": fixed}},
+	})
+	assert.Empty(t, c.diagnostics(uri))
+
+	var shutdown interface{}
+	c.call("shutdown", nil, &shutdown)
+	c.notify("exit", nil)
+	assert.NoError(t, <-done)
+}
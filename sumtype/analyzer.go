@@ -0,0 +1,81 @@
+package sumtype
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer checks that type switches over declared sum types are exhaustive.
+// Sum types declared in dependencies of the package being analyzed are
+// communicated to it via facts, so switches over them are checked too.
+//
+// In addition to reporting diagnostics, the analyzer's result is the list of
+// errors found in the package, each of which is one of the error types
+// defined in this package.
+var Analyzer = &analysis.Analyzer{
+	Name:       "sumtype",
+	Doc:        "check exhaustiveness of type switches over declared sum types",
+	URL:        "https://github.com/BurntSushi/go-sumtype",
+	Run:        run,
+	ResultType: reflect.TypeOf([]error(nil)),
+	FactTypes:  []analysis.Fact{new(sumTypeFact), new(noReturnFact)},
+}
+
+// diagnostic is implemented by every error reported by the analyzer.
+type diagnostic interface {
+	error
+	diagnostic() analysis.Diagnostic
+}
+
+// joinDiagnostics joins the given errors, each prefixed with the position of
+// its diagnostic in the given file set, e.g., "shape.go:3:1: ...". The
+// joined errors still wrap the given ones.
+func joinDiagnostics(fset *token.FileSet, errs []error) error {
+	positioned := make([]error, len(errs))
+	for i, err := range errs {
+		positioned[i] = err
+		if d, ok := err.(diagnostic); ok {
+			pos := fset.Position(d.diagnostic().Pos)
+			positioned[i] = fmt.Errorf("%s: %w", pos, err)
+		}
+	}
+	return errors.Join(positioned...)
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	errs := exportNoReturnFacts(pass)
+
+	decls := findSumTypeDecls(pass.Pkg, pass.Files)
+	decls = append(decls, cfg.sumTypeDecls(pass.Pkg, pass.Files, decls)...)
+	defs, defErrs := findSumTypeDefs(decls)
+	errs = append(errs, defErrs...)
+	errs = append(errs, cfg.importedErrors(pass)...)
+	errs = append(errs, checkEmbedding(defs)...)
+	exportSumTypeFacts(pass, defs)
+	imported := importSumTypeDefs(pass)
+	if reportEmbedders {
+		errs = append(errs, checkEmbedders(pass, imported)...)
+	}
+	defs = append(defs, imported...)
+	if len(defs) > 0 {
+		errs = append(errs, check(pass, cfg, defs)...)
+	}
+	var reported []error
+	for _, err := range errs {
+		diag := err.(diagnostic).diagnostic()
+		if cfg.excluded(pass, diag.Pos) {
+			continue
+		}
+		pass.Report(diag)
+		reported = append(reported, err)
+	}
+	return reported, nil
+}
@@ -0,0 +1,230 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// staleReason describes why an annotation on case analysis has no effect.
+type staleReason int
+
+const (
+	// notCaseAnalysis is an annotation that isn't on case analysis over a
+	// sum type.
+	notCaseAnalysis staleReason = iota
+	// suppressesNothing is a `go-sumtype:ignore` annotation on case
+	// analysis that has no errors to suppress.
+	suppressesNothing
+	// handledVariant is a variant named by a `go-sumtype:partial`
+	// annotation that is handled by the case analysis.
+	handledVariant
+	// unknownVariant is a name in a `go-sumtype:partial` annotation that
+	// isn't a variant of the sum type.
+	unknownVariant
+)
+
+// staleAnnotationError is returned for every `go-sumtype:ignore` or
+// `go-sumtype:partial` annotation, or name in such an annotation, that has no
+// effect. Such annotations are typically left behind when case analysis or a
+// sum type changes.
+type staleAnnotationError struct {
+	Pos token.Pos
+	End token.Pos
+	// The annotation's directive, i.e., "ignore" or "partial".
+	Directive string
+	Reason    staleReason
+	// The sum type the annotated case analysis is over, if any.
+	SumType string
+	// The offending name in a partial annotation, if any.
+	Name string
+}
+
+func (e staleAnnotationError) Error() string {
+	switch e.Reason {
+	case suppressesNothing:
+		return fmt.Sprintf(
+			"go-sumtype:%s annotation suppresses no errors for sum type '%s'",
+			e.Directive, e.SumType)
+	case handledVariant:
+		return fmt.Sprintf(
+			"variant '%s' named by go-sumtype:%s annotation is handled",
+			e.Name, e.Directive)
+	case unknownVariant:
+		return fmt.Sprintf(
+			"'%s' named by go-sumtype:%s annotation is not a variant of sum type '%s'",
+			e.Name, e.Directive, e.SumType)
+	default:
+		return fmt.Sprintf(
+			"go-sumtype:%s annotation is not on case analysis over a sum type",
+			e.Directive)
+	}
+}
+
+func (e staleAnnotationError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RuleStaleAnnotation,
+		Message:  e.Error(),
+	}
+}
+
+var reParseAnnotation = regexp.MustCompile(`^//go-sumtype:(ignore|partial)((?:\s+\S+)*)\s*$`)
+
+// annotation is a `//go-sumtype:ignore` or `//go-sumtype:partial ...` comment,
+// which applies to the case analysis (a switch statement or chain of
+// if/else-if statements) that starts on the same line or the next line.
+//
+// An ignore annotation suppresses every error for the case analysis. A
+// partial annotation acknowledges that the named variants are deliberately
+// not handled, without suppressing errors for any other missing variants.
+type annotation struct {
+	Pos token.Pos
+	End token.Pos
+	// The annotation's directive, i.e., "ignore" or "partial".
+	Directive string
+	// The variants named by a partial annotation.
+	Names []string
+
+	// Whether the annotation has been attached to case analysis.
+	attached bool
+	// The sum type of the annotated case analysis, if any.
+	def *sumTypeDef
+	// The names that were acknowledged as missing variants.
+	acknowledged map[string]bool
+}
+
+// annotations are the annotations in a file, keyed by line.
+type annotations map[int]*annotation
+
+// findAnnotations returns the annotations in the given file.
+func findAnnotations(pass *analysis.Pass, file *ast.File) annotations {
+	anns := make(annotations)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			caps := reParseAnnotation.FindStringSubmatch(c.Text)
+			if caps == nil {
+				continue
+			}
+			ann := &annotation{
+				Pos:          c.Pos(),
+				End:          c.End(),
+				Directive:    caps[1],
+				Names:        strings.Fields(caps[2]),
+				acknowledged: make(map[string]bool),
+			}
+			switch {
+			case ann.Directive == "ignore":
+				// Anything following an ignore annotation is an
+				// explanation for it.
+				ann.Names = nil
+			case len(ann.Names) == 0:
+				continue
+			}
+			anns[lineOf(pass, c.Pos())] = ann
+		}
+	}
+	return anns
+}
+
+// attach returns the annotation that applies to the case analysis starting
+// with the given statement, if any, and marks it as attached.
+func (anns annotations) attach(pass *analysis.Pass, stmt ast.Stmt) *annotation {
+	line := lineOf(pass, stmt.Pos())
+	for _, l := range []int{line, line - 1} {
+		if ann, ok := anns[l]; ok && !ann.attached {
+			ann.attached = true
+			return ann
+		}
+	}
+	return nil
+}
+
+// unused returns an error for every annotation that was never attached to
+// case analysis over a sum type.
+func (anns annotations) unused() []error {
+	var errs []error
+	for _, ann := range anns {
+		if ann.def == nil {
+			errs = append(errs, staleAnnotationError{
+				Pos:       ann.Pos,
+				End:       ann.End,
+				Directive: ann.Directive,
+				Reason:    notCaseAnalysis,
+			})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].(staleAnnotationError).Pos < errs[j].(staleAnnotationError).Pos
+	})
+	return errs
+}
+
+// acknowledge records that the annotated case analysis is over the given sum
+// type, and returns the given missing variants without those acknowledged
+// by a partial annotation.
+func (ann *annotation) acknowledge(def *sumTypeDef, missing []types.Object) []types.Object {
+	if ann == nil || def == nil {
+		return missing
+	}
+	ann.def = def
+	var remaining []types.Object
+	for _, v := range missing {
+		acked := false
+		for _, name := range ann.Names {
+			if v.Name() == name {
+				acked = true
+				ann.acknowledged[name] = true
+			}
+		}
+		if !acked {
+			remaining = append(remaining, v)
+		}
+	}
+	return remaining
+}
+
+// filter returns the given errors, found in the annotated case analysis,
+// along with an error for every part of the annotation that has no effect.
+// If the annotation is an ignore annotation, then the given errors are
+// suppressed.
+func (ann *annotation) filter(errs []error) []error {
+	if ann == nil || ann.def == nil {
+		return errs
+	}
+	stale := staleAnnotationError{
+		Pos:       ann.Pos,
+		End:       ann.End,
+		Directive: ann.Directive,
+		SumType:   ann.def.Decl.TypeName,
+	}
+	if ann.Directive == "ignore" {
+		if len(errs) == 0 {
+			stale.Reason = suppressesNothing
+			return []error{stale}
+		}
+		return nil
+	}
+	for _, name := range ann.Names {
+		if ann.acknowledged[name] {
+			continue
+		}
+		stale.Name = name
+		stale.Reason = unknownVariant
+		for _, v := range ann.def.Variants {
+			if v.Name() == name {
+				stale.Reason = handledVariant
+				break
+			}
+		}
+		errs = append(errs, stale)
+	}
+	return errs
+}
@@ -0,0 +1,112 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// reportAssertions is set by the analyzer's -assertions flag.
+var reportAssertions bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&reportAssertions, "assertions", false,
+		"report single-value type assertions on sum types outside of test files")
+}
+
+// panickingAssertError is returned from check for each single-value type
+// assertion on a sum type (when enabled). Such an assertion is a hidden
+// partial match: it panics for every variant it doesn't match.
+type panickingAssertError struct {
+	Pos    token.Pos
+	End    token.Pos
+	Def    sumTypeDef
+	Type   types.Type
+	Panics []types.Object
+}
+
+func (e panickingAssertError) Error() string {
+	return fmt.Sprintf(
+		"single-value type assertion to %s on sum type '%s' panics for %s",
+		types.TypeString(e.Type, e.qualifier), e.Def.Decl.TypeName,
+		strings.Join(e.Names(), ", "))
+}
+
+// qualifier qualifies types by package name, unless they are declared in the
+// same package as the sum type.
+func (e panickingAssertError) qualifier(pkg *types.Package) string {
+	if pkg.Path() == e.Def.Decl.Package.Path() {
+		return ""
+	}
+	return pkg.Name()
+}
+
+func (e panickingAssertError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RulePanickingAssertion,
+		Message:  e.Error(),
+	}
+}
+
+// Names returns a sorted list of names corresponding to the variants for
+// which the type assertion panics.
+func (e panickingAssertError) Names() []string {
+	return inexhaustiveError{Missing: e.Panics}.Names()
+}
+
+// checkTypeAssert checks the given type assertion, which must be used in a
+// single-value context. If it asserts a value of a sum type to be one of its
+// variants, then an error is returned indicating the variants for which the
+// assertion panics.
+func checkTypeAssert(
+	pass *analysis.Pass,
+	idx *defIndex,
+	expr *ast.TypeAssertExpr,
+) error {
+	ty := pass.TypesInfo.TypeOf(expr.X)
+	def := idx.find(ty)
+	if def == nil {
+		return nil
+	}
+	asserted := pass.TypesInfo.TypeOf(expr.Type)
+	panics := def.missing(ty, []types.Type{asserted})
+	if len(panics) == 0 {
+		return nil
+	}
+	return panickingAssertError{
+		Pos:    expr.Pos(),
+		End:    expr.End(),
+		Def:    *def,
+		Type:   asserted,
+		Panics: panics,
+	}
+}
+
+// commaOkAsserts adds to the given set every type assertion whose result is
+// assigned to two values by the given node, i.e., `v, ok := x.(T)`.
+func commaOkAsserts(set map[*ast.TypeAssertExpr]bool, n ast.Node) {
+	var rhs []ast.Expr
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		if len(n.Lhs) == 2 {
+			rhs = n.Rhs
+		}
+	case *ast.ValueSpec:
+		if len(n.Names) == 2 {
+			rhs = n.Values
+		}
+	}
+	if len(rhs) != 1 {
+		return
+	}
+	if expr, ok := astutil.Unparen(rhs[0]).(*ast.TypeAssertExpr); ok {
+		set[expr] = true
+	}
+}
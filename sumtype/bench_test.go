@@ -0,0 +1,106 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+// sumTypeSource returns the source code of a package named pkgName that
+// declares a sum type T with n variants, named V0 through V(n-1).
+func sumTypeSource(pkgName string, n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n//go-sumtype:decl T\n\ntype T interface{ sealed() }\n", pkgName)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\ntype V%d struct{}\n\nfunc (*V%d) sealed() {}\n", i, i)
+	}
+	return b.String()
+}
+
+// switchSource returns the source code of a function named name with a type
+// switch over a value of the sum type from sumTypeSource, with cases for the
+// first n of its variants. qual qualifies the names of the types, and is
+// empty if they're declared in the same package.
+func switchSource(name, qual string, n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nfunc %s(x %sT) {\n\tswitch x.(type) {\n", name, qual)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\tcase *%sV%d:\n", qual, i)
+	}
+	b.WriteString("\t}\n}\n")
+	return b.String()
+}
+
+// BenchmarkMissing benchmarks finding the missing variants of a type switch
+// over a sum type with many variants, which takes time linear in the number
+// of variants.
+func BenchmarkMissing(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("variants=%d", n), func(b *testing.B) {
+			code := sumTypeSource("sumtype", n) + switchSource("F", "", n-1)
+			tmpdir, pkgs := setupPackages(b, code)
+			defer teardownPackage(b, tmpdir)
+
+			pkg := pkgs[0]
+			defs, errs := findSumTypeDefs(findSumTypeDecls(pkg.Types, pkg.Syntax))
+			if len(errs) > 0 || len(defs) != 1 {
+				b.Fatalf("expected one sum type, got %d (errors: %v)", len(defs), errs)
+			}
+			var swtch *ast.TypeSwitchStmt
+			ast.Inspect(pkg.Syntax[0], func(n ast.Node) bool {
+				if n, ok := n.(*ast.TypeSwitchStmt); ok {
+					swtch = n
+				}
+				return swtch == nil
+			})
+			sumTy := pkg.TypesInfo.TypeOf(findTypeAssertExpr(swtch))
+			exprs, _ := switchVariants(swtch.Body)
+			var tys []types.Type
+			for _, expr := range exprs {
+				tys = append(tys, pkg.TypesInfo.TypeOf(expr))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if missing := defs[0].missing(sumTy, tys); len(missing) != 1 {
+					b.Fatalf("expected one missing variant, got %d", len(missing))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAnalyzer benchmarks running the analyzer over a synthetic module
+// in which many packages, each with many files, switch over a sum type with
+// many variants declared in another package. Loading the packages isn't
+// included in the time measured.
+func BenchmarkAnalyzer(b *testing.B) {
+	const variants = 500
+	const filesPerPackage = 8
+	for _, numPkgs := range []int{1, 16} {
+		b.Run(fmt.Sprintf("packages=%d", numPkgs), func(b *testing.B) {
+			files := map[string]string{
+				"st/st.go": sumTypeSource("st", variants),
+			}
+			for p := 0; p < numPkgs; p++ {
+				for f := 0; f < filesPerPackage; f++ {
+					name := fmt.Sprintf("p%d/f%d.go", p, f)
+					files[name] = fmt.Sprintf(
+						"package p%d\n\nimport \"example.com/m/st\"\n", p) +
+						switchSource(fmt.Sprintf("F%d", f), "st.", variants)
+				}
+			}
+			tmpdir, pkgs := setupModule(b, files, "./...")
+			defer teardownPackage(b, tmpdir)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if errs := runAnalyzer(b, pkgs); len(errs) > 0 {
+					b.Fatalf("expected no errors, got %v", errs)
+				}
+			}
+		})
+	}
+}
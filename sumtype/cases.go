@@ -0,0 +1,235 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// unreachableReason describes why a case clause can never be selected.
+type unreachableReason int
+
+const (
+	// notVariant is a case that matches no variant of the sum type.
+	notVariant unreachableReason = iota
+	// duplicateCase is a case that is the same as an earlier case.
+	duplicateCase
+	// shadowedCase is a case whose variants are all matched by an earlier
+	// case.
+	shadowedCase
+)
+
+// unreachableCaseError is returned from check for each case in case analysis
+// over a sum type that can never be selected. Such cases are typically left
+// behind when variants are removed or renamed.
+type unreachableCaseError struct {
+	Pos    token.Pos
+	End    token.Pos
+	Def    sumTypeDef
+	Reason unreachableReason
+	// The offending case, as written in the source.
+	Case string
+	// The earlier case that the offending case duplicates or is shadowed
+	// by, as written in the source. This is empty when Reason is
+	// notVariant.
+	Earlier string
+}
+
+func (e unreachableCaseError) Error() string {
+	switch e.Reason {
+	case duplicateCase:
+		return fmt.Sprintf(
+			"case %s for sum type '%s' duplicates earlier case %s",
+			e.Case, e.Def.Decl.TypeName, e.Earlier)
+	case shadowedCase:
+		return fmt.Sprintf(
+			"case %s for sum type '%s' is shadowed by earlier case %s",
+			e.Case, e.Def.Decl.TypeName, e.Earlier)
+	default:
+		return fmt.Sprintf(
+			"case %s matches no variant of sum type '%s'",
+			e.Case, e.Def.Decl.TypeName)
+	}
+}
+
+func (e unreachableCaseError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RuleUnreachableCase,
+		Message:  e.Error(),
+	}
+}
+
+// checkTypeSwitchCases reports every case in the given type switch that can
+// never be selected, if the type switch is over a sum type.
+func checkTypeSwitchCases(
+	pass *analysis.Pass,
+	idx *defIndex,
+	swtch *ast.TypeSwitchStmt,
+) []error {
+	ty := pass.TypesInfo.TypeOf(findTypeAssertExpr(swtch))
+	def := idx.find(ty)
+	if def == nil {
+		return nil
+	}
+	exprs, _ := switchVariants(swtch.Body)
+	return checkTypeCases(pass, def, ty, exprs)
+}
+
+// checkTypeCases reports every one of the given case expressions, in order,
+// that can never be selected when matching a value of type sumTy, which is
+// an instantiation of the given sum type.
+//
+// A case can never be selected when its type isn't a variant (or, if it's an
+// interface, when no variant implements it), when it's the same as an
+// earlier case, or when every variant it matches is matched by an earlier
+// interface case.
+func checkTypeCases(
+	pass *analysis.Pass,
+	def *sumTypeDef,
+	sumTy types.Type,
+	exprs []ast.Expr,
+) []error {
+	var errs []error
+	report := func(expr ast.Expr, reason unreachableReason, earlier ast.Expr) {
+		err := unreachableCaseError{
+			Pos:    expr.Pos(),
+			End:    expr.End(),
+			Def:    *def,
+			Reason: reason,
+			Case:   types.ExprString(expr),
+		}
+		if earlier != nil {
+			err.Earlier = types.ExprString(earlier)
+		}
+		errs = append(errs, err)
+	}
+	// The earlier cases, indexed by type so that duplicates are found in
+	// constant time. Only interface cases can shadow later cases, so only
+	// those are checked one by one.
+	var earlier []ast.Expr
+	byType := newTypeIndex()
+	var ifaces []int
+	for _, expr := range exprs {
+		ty := pass.TypesInfo.TypeOf(expr)
+		if ty == nil || isNil(ty) {
+			continue
+		}
+		if dups := byType.lookup(ty); len(dups) > 0 {
+			report(expr, duplicateCase, earlier[dups[0]])
+			continue
+		}
+		if !def.matchesVariant(sumTy, ty) {
+			report(expr, notVariant, nil)
+		} else if shadow := findShadow(pass, earlier, ifaces, ty); shadow != nil {
+			report(expr, shadowedCase, shadow)
+		}
+		byType.add(ty, len(earlier))
+		if types.IsInterface(ty) {
+			ifaces = append(ifaces, len(earlier))
+		}
+		earlier = append(earlier, expr)
+	}
+	return errs
+}
+
+// matchesVariant returns true if and only if a case of the given type can
+// match a variant of this sum type, instantiated for use with a value of
+// type sumTy.
+//
+// An interface type matches every variant whose values implement it. Any
+// other type matches a variant only if it is that variant and implements the
+// sum type. (A value type whose methods all have pointer receivers doesn't
+// implement the sum type, but its pointer does.)
+func (def *sumTypeDef) matchesVariant(sumTy types.Type, ty types.Type) bool {
+	vi := def.variants(sumTy)
+	sumIface, ok := sumTy.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	if iface, ok := ty.Underlying().(*types.Interface); ok {
+		for _, varty := range vi.types {
+			if valty := valueType(varty, sumIface); valty != nil && types.Implements(valty, iface) {
+				return true
+			}
+		}
+		return false
+	}
+	if !types.Implements(ty, sumIface) {
+		return false
+	}
+	return len(vi.byType.lookup(indirect(ty))) > 0
+}
+
+// checkEnumSwitchCases reports every case in the given expression switch that
+// can never be selected, if the switch is over an enum. That is, every
+// constant case whose value isn't the value of any of the enum's constants,
+// and every constant case whose value is the same as an earlier case.
+func checkEnumSwitchCases(
+	pass *analysis.Pass,
+	idx *defIndex,
+	swtch *ast.SwitchStmt,
+) []error {
+	if swtch.Tag == nil {
+		return nil
+	}
+	def := idx.findEnum(pass.TypesInfo.TypeOf(swtch.Tag))
+	if def == nil {
+		return nil
+	}
+	exprs, _ := switchVariants(swtch.Body)
+	var errs []error
+	seen := make(map[string]ast.Expr)
+	for _, expr := range exprs {
+		val := pass.TypesInfo.Types[expr].Value
+		if val == nil {
+			continue
+		}
+		err := unreachableCaseError{
+			Pos:  expr.Pos(),
+			End:  expr.End(),
+			Def:  *def,
+			Case: types.ExprString(expr),
+		}
+		if prev, ok := seen[val.ExactString()]; ok {
+			err.Reason = duplicateCase
+			err.Earlier = types.ExprString(prev)
+			errs = append(errs, err)
+			continue
+		}
+		seen[val.ExactString()] = expr
+		if len(def.missingValues([]constant.Value{val})) == len(def.Variants) {
+			err.Reason = notVariant
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// findShadow returns the first of the given earlier case expressions, at
+// the given indexes of interface cases, whose interface is implemented by
+// the given type. If there is no such expression, then nil is returned.
+//
+// Only the methods of the given type itself count, since a value of type A
+// doesn't match an interface that only *A implements.
+func findShadow(pass *analysis.Pass, earlier []ast.Expr, ifaces []int, ty types.Type) ast.Expr {
+	for _, i := range ifaces {
+		prev := pass.TypesInfo.TypeOf(earlier[i])
+		if types.Implements(ty, prev.Underlying().(*types.Interface)) {
+			return earlier[i]
+		}
+	}
+	return nil
+}
+
+// isNil returns true if and only if the given type is the type of the
+// predeclared nil, as used in `case nil:`.
+func isNil(ty types.Type) bool {
+	basic, ok := ty.(*types.Basic)
+	return ok && basic.Kind() == types.UntypedNil
+}
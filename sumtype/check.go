@@ -0,0 +1,262 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// inexhaustiveError is returned from check for each occurrence of inexhaustive
+// case analysis in a Go type switch statement.
+type inexhaustiveError struct {
+	Pos     token.Pos
+	End     token.Pos
+	Def     sumTypeDef
+	Missing []types.Object
+	// Fixes that add the missing cases, if any.
+	Fixes []analysis.SuggestedFix
+}
+
+func (e inexhaustiveError) Error() string {
+	return fmt.Sprintf(
+		"exhaustiveness check failed for sum type '%s': missing cases for %s",
+		e.Def.Decl.TypeName, strings.Join(e.Names(), ", "))
+}
+
+func (e inexhaustiveError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:            e.Pos,
+		End:            e.End,
+		Category:       RuleInexhaustive,
+		Message:        e.Error(),
+		SuggestedFixes: e.Fixes,
+	}
+}
+
+// Names returns a sorted list of names corresponding to the missing variant
+// cases.
+func (e inexhaustiveError) Names() []string {
+	var list []string
+	for _, o := range e.Missing {
+		list = append(list, o.Name())
+	}
+	sort.Sort(sort.StringSlice(list))
+	return list
+}
+
+// check does exhaustiveness checking for the given sum type definitions in the
+// given package. Every instance of inexhaustive case analysis is returned,
+// along with every case in case analysis over a sum type that can never be
+// selected.
+//
+// Case analysis may be annotated with `//go-sumtype:ignore` or
+// `//go-sumtype:partial ...` (see annotation), in which case its errors are
+// filtered accordingly and annotations that have no effect are reported.
+//
+// If enabled, either for all sum types or for particular sum types by the
+// given configuration, single-value type assertions on sum types outside of
+// test files are reported too.
+//
+// The files of the package are checked concurrently. Errors are returned in
+// the order of the files they're found in, regardless.
+func check(pass *analysis.Pass, cfg *config, defs []sumTypeDef) []error {
+	idx := newDefIndex(defs)
+	// Files other than test files are checked without the variants
+	// declared in test files, which only the package's test variant has.
+	nonTestIdx := idx
+	if nonTest := withoutTestVariants(pass.Fset, defs); nonTest != nil {
+		nonTestIdx = newDefIndex(nonTest)
+	}
+	fileErrs := make([][]error, len(pass.Files))
+	// Checking a file only reads the index and the package's type
+	// information, so no synchronization is needed beyond limiting the
+	// number of files checked at once.
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, astfile := range pass.Files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, astfile *ast.File) {
+			defer wg.Done()
+			fileIdx := idx
+			if !isTestFile(pass, astfile) {
+				fileIdx = nonTestIdx
+			}
+			fileErrs[i] = checkFile(pass, cfg, fileIdx, astfile)
+			<-sem
+		}(i, astfile)
+	}
+	wg.Wait()
+	var errs []error
+	for _, ferrs := range fileErrs {
+		errs = append(errs, ferrs...)
+	}
+	return errs
+}
+
+// isTestFile returns true if and only if the given file of the package is a
+// test file.
+func isTestFile(pass *analysis.Pass, astfile *ast.File) bool {
+	return strings.HasSuffix(pass.Fset.File(astfile.Pos()).Name(), "_test.go")
+}
+
+// checkFile does exhaustiveness checking for the sum types in the given index
+// in a single file of the package. (See check.)
+func checkFile(pass *analysis.Pass, cfg *config, idx *defIndex, astfile *ast.File) []error {
+	var errs []error
+	checkAsserts := !isTestFile(pass, astfile)
+	commaOk := make(map[*ast.TypeAssertExpr]bool)
+	inChain := make(map[*ast.IfStmt]bool)
+	anns := findAnnotations(pass, astfile)
+	ast.Inspect(astfile, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSwitchStmt:
+			ann := anns.attach(pass, n)
+			var stmtErrs []error
+			if err := checkSwitch(pass, idx, n, ann); err != nil {
+				stmtErrs = append(stmtErrs, err)
+			}
+			stmtErrs = append(stmtErrs, checkTypeSwitchCases(pass, idx, n)...)
+			errs = append(errs, ann.filter(stmtErrs)...)
+		case *ast.SwitchStmt:
+			ann := anns.attach(pass, n)
+			var stmtErrs []error
+			if err := checkEnumSwitch(pass, idx, n, ann); err != nil {
+				stmtErrs = append(stmtErrs, err)
+			}
+			stmtErrs = append(stmtErrs, checkEnumSwitchCases(pass, idx, n)...)
+			errs = append(errs, ann.filter(stmtErrs)...)
+		case *ast.IfStmt:
+			if !inChain[n] {
+				ann := anns.attach(pass, n)
+				stmtErrs := checkIfChain(pass, idx, n, inChain, ann)
+				errs = append(errs, ann.filter(stmtErrs)...)
+			}
+		case *ast.AssignStmt, *ast.ValueSpec:
+			commaOkAsserts(commaOk, n)
+		case *ast.TypeAssertExpr:
+			if checkAsserts && n.Type != nil && !commaOk[n] {
+				err := checkTypeAssert(pass, idx, n)
+				if err, ok := err.(panickingAssertError); ok && cfg.assertions(&err.Def) {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return true
+	})
+	errs = append(errs, anns.unused()...)
+	return errs
+}
+
+// checkSwitch performs an exhaustiveness check on the given type switch
+// statement. If the type switch is used on a sum type and does not cover
+// all variants of that sum type, then an error is returned indicating which
+// variants were missed.
+//
+// Note that if the type switch contains a default case that may complete
+// normally, then exhaustiveness checks are disabled.
+//
+// Variants acknowledged by the given annotation, if any, aren't missing.
+func checkSwitch(
+	pass *analysis.Pass,
+	idx *defIndex,
+	swtch *ast.TypeSwitchStmt,
+	ann *annotation,
+) error {
+	def, missing := missingVariantsInSwitch(pass, idx, swtch)
+	missing = ann.acknowledge(def, missing)
+	if len(missing) > 0 {
+		ty := pass.TypesInfo.TypeOf(findTypeAssertExpr(swtch))
+		return inexhaustiveError{
+			Pos:     swtch.Pos(),
+			End:     swtch.Body.Lbrace,
+			Def:     *def,
+			Missing: missing,
+			Fixes:   suggestMissingCases(pass, def, ty, swtch, swtch.Body, missing),
+		}
+	}
+	return nil
+}
+
+// missingVariantsInSwitch returns a list of missing variants corresponding to
+// the given switch statement. The corresponding sum type definition is also
+// returned. (If no sum type definition could be found, then no exhaustiveness
+// checks are performed, and therefore, no missing variants are returned.)
+func missingVariantsInSwitch(
+	pass *analysis.Pass,
+	idx *defIndex,
+	swtch *ast.TypeSwitchStmt,
+) (*sumTypeDef, []types.Object) {
+	asserted := findTypeAssertExpr(swtch)
+	ty := pass.TypesInfo.TypeOf(asserted)
+	def := idx.find(ty)
+	if def == nil {
+		// We couldn't find a corresponding sum type, so there's
+		// nothing we can do to check it.
+		return nil, nil
+	}
+	variantExprs, hasDefault := switchVariants(swtch.Body)
+	if hasDefault && !defaultClauseNeverCompletes(pass, swtch.Body) {
+		// A catch-all case defeats all exhaustiveness checks.
+		return def, nil
+	}
+	var variantTypes []types.Type
+	for _, expr := range variantExprs {
+		variantTypes = append(variantTypes, pass.TypesInfo.TypeOf(expr))
+	}
+	return def, def.missing(ty, variantTypes)
+}
+
+// switchVariants returns all case expressions found in the body of a switch.
+// This includes expressions from cases that have a list of expressions.
+func switchVariants(body *ast.BlockStmt) (exprs []ast.Expr, hasDefault bool) {
+	for _, stmt := range body.List {
+		clause := stmt.(*ast.CaseClause)
+		if clause.List == nil {
+			hasDefault = true
+		} else {
+			exprs = append(exprs, clause.List...)
+		}
+	}
+	return
+}
+
+// defaultClauseNeverCompletes returns true if the given switch statement body
+// has a default clause that never completes normally, e.g., because it always
+// panics. (See neverCompletes.)
+//
+// If the given switch statement body has no default clause, then this function
+// panics.
+func defaultClauseNeverCompletes(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	var clause *ast.CaseClause
+	for _, stmt := range body.List {
+		c := stmt.(*ast.CaseClause)
+		if c.List == nil {
+			clause = c
+			break
+		}
+	}
+	if clause == nil {
+		panic("switch statement has no default clause")
+	}
+	return neverCompletes(pass, clause.Body)
+}
+
+// findTypeAssertExpr extracts the expression that is being type asserted from a
+// type swtich statement.
+func findTypeAssertExpr(swtch *ast.TypeSwitchStmt) ast.Expr {
+	var expr ast.Expr
+	if assign, ok := swtch.Assign.(*ast.AssignStmt); ok {
+		expr = assign.Rhs[0]
+	} else {
+		expr = swtch.Assign.(*ast.ExprStmt).X
+	}
+	return expr.(*ast.TypeAssertExpr).X
+}
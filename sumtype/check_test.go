@@ -0,0 +1,1396 @@
+package sumtype
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMissingOne tests that we detect a single missing variant.
+func TestMissingOne(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+}
+
+// TestMissingTwo tests that we detect a two missing variants.
+func TestMissingTwo(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"B", "C"}, missingNames(t, errs[0]))
+}
+
+// TestMissingOneWithPanic tests that we detect a single missing variant even
+// if we have a trivial default case that panics.
+func TestMissingOneWithPanic(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	default:
+		panic("unreachable")
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+}
+
+// TestMissingWithDivergingDefault tests that we detect missing variants when
+// the default case never completes normally, even if it doesn't consist of a
+// single call to panic.
+func TestMissingWithDivergingDefault(t *testing.T) {
+	files := map[string]string{
+		"must/must.go": `
+package must
+
+// Unreachable panics.
+//
+//go-sumtype:noreturn
+func Unreachable(v interface{}) {
+	panic(v)
+}
+`,
+		"main.go": `
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"example.com/m/must"
+)
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	x := T(nil)
+	switch x.(type) {
+	case *A:
+	default:
+		log.Fatalf("unexpected %T", x)
+	}
+	switch x.(type) {
+	case *A:
+	default:
+		log.Printf("unexpected %T", x)
+		panic(fmt.Sprintf("unexpected %T", x))
+	}
+	switch x.(type) {
+	case *A:
+	default:
+		if x == nil {
+			os.Exit(1)
+		}
+		must.Unreachable(x)
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 3) {
+		t.FailNow()
+	}
+	for _, err := range errs {
+		assert.Equal(t, []string{"B"}, missingNames(t, err))
+	}
+}
+
+// TestNoMissingCompletingDefault tests that a default case that may complete
+// normally thwarts exhaustiveness checking, even if it sometimes panics.
+func TestNoMissingCompletingDefault(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	x := T(nil)
+	for {
+		switch x.(type) {
+		case *A:
+		default:
+			if x == nil {
+				break
+			}
+			panic("unreachable")
+		}
+		switch x.(type) {
+		case *A:
+		default:
+			if x == nil {
+				continue
+			}
+			panic("unreachable")
+		}
+		switch x.(type) {
+		case *A:
+		default:
+			if x == nil {
+				return
+			}
+			panic("unreachable")
+		}
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestNoReturnMayReturn tests that we report an error for a function that is
+// annotated as never returning, but may return.
+func TestNoReturnMayReturn(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:noreturn
+func fatal(ok bool) {
+	if ok {
+		return
+	}
+	panic("fatal")
+}
+
+func main() {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "fatal", errs[0].(noReturnError).Name)
+}
+
+// TestSuggestedFix tests that the fix suggested for inexhaustive case analysis
+// inserts a case for each missing variant before a panicking default case,
+// importing the variants' package if necessary.
+func TestSuggestedFix(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `package ast
+
+//go-sumtype:decl Expr
+
+type Expr interface{ sealed() }
+
+type Ident struct{}
+
+func (*Ident) sealed() {}
+
+type Lit struct{}
+
+func (Lit) sealed() {}
+
+type Call struct{}
+
+func (*Call) sealed() {}
+
+func Parse() Expr { return nil }
+`,
+		"main.go": `package main
+
+import "example.com/m/ast"
+
+func main() {
+	switch ast.Parse().(type) {
+	case *ast.Ident:
+	default:
+		panic("unreachable")
+	}
+}
+`,
+		"other.go": `package main
+
+func other() {
+	switch main2() {
+	}
+}
+
+func main2() Color { return Red }
+
+//go-sumtype:enum Color
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	fixes := errs[0].(inexhaustiveError).Fixes
+	if !assert.Len(t, fixes, 1) {
+		t.FailNow()
+	}
+	got := applyFix(t, pkgs[0].Fset, filepath.Join(tmpdir, "main.go"), fixes[0])
+	assert.Equal(t, `package main
+
+import "example.com/m/ast"
+
+func main() {
+	switch ast.Parse().(type) {
+	case *ast.Ident:
+	case ast.Lit:
+		panic("TODO")
+	case *ast.Call:
+		panic("TODO")
+	default:
+		panic("unreachable")
+	}
+}
+`, got)
+
+	fixes = errs[1].(inexhaustiveError).Fixes
+	if !assert.Len(t, fixes, 1) {
+		t.FailNow()
+	}
+	got = applyFix(t, pkgs[0].Fset, filepath.Join(tmpdir, "other.go"), fixes[0])
+	assert.Contains(t, got, `	switch main2() {
+	case Red:
+		panic("TODO")
+	case Green:
+		panic("TODO")
+	}
+`)
+}
+
+// TestSuggestedFixUnexported tests that the fix suggested for inexhaustive
+// case analysis doesn't insert cases for unexported variants of other
+// packages, which can't be referred to, and that no fix is suggested if
+// only such variants are missing.
+func TestSuggestedFixUnexported(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `package ast
+
+//go-sumtype:decl Expr
+
+type Expr interface{ sealed() }
+
+type Ident struct{}
+
+func (*Ident) sealed() {}
+
+type lit struct{}
+
+func (*lit) sealed() {}
+
+//go-sumtype:enum Color
+
+type Color int
+
+const (
+	Red Color = iota
+	green
+)
+`,
+		"main.go": `package main
+
+import "example.com/m/ast"
+
+func expr(e ast.Expr) {
+	switch e.(type) {
+	}
+}
+
+func ident(e ast.Expr) {
+	switch e.(type) {
+	case *ast.Ident:
+	}
+}
+
+func color(c ast.Color) {
+	switch c {
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 3) {
+		t.FailNow()
+	}
+	path := filepath.Join(tmpdir, "main.go")
+	fixes := errs[0].(inexhaustiveError).Fixes
+	if assert.Len(t, fixes, 1) {
+		assert.Equal(t, "Add cases for Ident", fixes[0].Message)
+		got := applyFix(t, pkgs[0].Fset, path, fixes[0])
+		assert.Contains(t, got, `	switch e.(type) {
+	case *ast.Ident:
+		panic("TODO")
+	}
+`)
+	}
+	assert.Empty(t, errs[1].(inexhaustiveError).Fixes)
+	fixes = errs[2].(inexhaustiveError).Fixes
+	if assert.Len(t, fixes, 1) {
+		got := applyFix(t, pkgs[0].Fset, path, fixes[0])
+		assert.Contains(t, got, `	switch c {
+	case ast.Red:
+		panic("TODO")
+	}
+`)
+	}
+}
+
+// TestNoMissing tests that we correctly detect exhaustive case analysis.
+func TestNoMissing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A, *B, *C:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestNoMissingDefault tests that even if we have a missing variant, a default
+// case should thwart exhaustiveness checking.
+func TestNoMissingDefault(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	default:
+		println("legit catch all goes here")
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestMissingImported tests that we detect a missing variant of a sum type
+// that is declared in a dependency of the package being checked, even when
+// the declaring package itself isn't being checked.
+func TestMissingImported(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Expr
+
+type Expr interface { sealed() }
+
+type Ident struct {}
+func (i *Ident) sealed() {}
+
+type Call struct {}
+func (c *Call) sealed() {}
+`,
+		"cmd/main.go": `
+package main
+
+import "example.com/m/ast"
+
+func main() {
+	switch ast.Expr(nil).(type) {
+	case *ast.Ident:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./cmd")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Call"}, missingNames(t, errs[0]))
+}
+
+// TestMissingWithTests tests that a package declaring a sum type can have both
+// in-package and external tests without producing spurious errors, and that
+// errors in the package are reported once even though the package is checked
+// both with and without its tests. Variants declared in test files are only
+// required in test files.
+func TestMissingWithTests(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Expr
+
+type Expr interface { sealed() }
+
+type Ident struct {}
+func (i *Ident) sealed() {}
+
+type Call struct {}
+func (c *Call) sealed() {}
+
+func use(e Expr) {
+	switch e.(type) {
+	case *Ident:
+	}
+}
+`,
+		"ast/ast_test.go": `
+package ast
+
+import "testing"
+
+type TB struct {}
+func (*TB) sealed() {}
+
+func TestIn(t *testing.T) {
+	switch Expr(nil).(type) {
+	case *Ident, *Call, *TB:
+	}
+}
+`,
+		"ast/ext_test.go": `
+package ast_test
+
+import (
+	"testing"
+
+	"example.com/m/ast"
+)
+
+func TestExt(t *testing.T) {
+	switch ast.Expr(nil).(type) {
+	case *ast.Call:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./ast")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Call"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"Ident", "TB"}, missingNames(t, errs[1]))
+}
+
+// TestNested tests that a nested sum type is covered by either a case for the
+// nested sum type itself or cases for all of its variants, and that interface
+// case clauses cover every variant implementing them.
+func TestNested(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl Node
+
+type Node interface { node() }
+
+type Expr interface { Node; expr() }
+
+type Stmt interface { Node; stmt() }
+
+type Ident struct {}
+func (*Ident) node() {}
+func (*Ident) expr() {}
+
+type Call struct {}
+func (*Call) node() {}
+func (*Call) expr() {}
+
+type Assign struct {}
+func (*Assign) node() {}
+func (*Assign) stmt() {}
+
+type File struct {}
+func (*File) node() {}
+
+func main() {
+	switch Node(nil).(type) {
+	case Expr, Stmt, *File:
+	}
+	switch Node(nil).(type) {
+	case *Ident, *Call, *Assign, *File:
+	}
+	switch Node(nil).(type) {
+	case interface{ expr() }, Stmt, *File:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestInterfaceCaseReceivers tests that an interface case clause only covers
+// the variants whose values implement the interface, which excludes variants
+// that aren't pointers when the interface's methods have pointer receivers.
+// A case for such a variant after the interface case isn't shadowed by it,
+// and an interface case that only such variants' pointers implement is
+// unreachable.
+func TestInterfaceCaseReceivers(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type I interface { m() }
+
+type J interface { n() }
+
+type A struct {}
+func (A) sealed() {}
+func (*A) m() {}
+func (*A) n() {}
+
+type B struct {}
+func (*B) sealed() {}
+func (*B) m() {}
+
+type C struct {}
+func (*C) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case I:
+	case *C:
+	}
+	switch T(nil).(type) {
+	case I:
+	case A, *C:
+	}
+	switch T(nil).(type) {
+	case J:
+	case A, *B, *C:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"A"}, missingNames(t, errs[0]))
+	assert.Equal(t, "case J matches no variant of sum type 'T'", errs[1].Error())
+}
+
+// TestNestedMissing tests that missing variants of a nested sum type are
+// reported individually when some of them are covered, and that the nested
+// sum type is reported in their place when none of them are.
+func TestNestedMissing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl Node
+
+type Node interface { node() }
+
+type Expr interface { Node; expr() }
+
+type Stmt interface { Node; stmt() }
+
+type Ident struct {}
+func (*Ident) node() {}
+func (*Ident) expr() {}
+
+type Call struct {}
+func (*Call) node() {}
+func (*Call) expr() {}
+
+type Assign struct {}
+func (*Assign) node() {}
+func (*Assign) stmt() {}
+
+type File struct {}
+func (*File) node() {}
+
+func main() {
+	switch Node(nil).(type) {
+	case *Ident:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Call", "File", "Stmt"}, missingNames(t, errs[0]))
+}
+
+// TestUnreachableCases tests that we report cases that can never be selected
+// in case analysis over a sum type.
+func TestUnreachableCases(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Node
+
+type Node interface { node() }
+
+type Expr interface { Node; expr() }
+
+type Ident struct {}
+func (*Ident) node() {}
+func (*Ident) expr() {}
+
+type File struct {}
+func (*File) node() {}
+`,
+		"main.go": `
+package main
+
+import (
+	"fmt"
+
+	"example.com/m/ast"
+)
+
+type MyIdent struct { *ast.Ident }
+
+func main() {
+	x := ast.Node(nil)
+	switch x.(type) {
+	case nil, ast.Expr, *ast.File:
+	case *ast.Ident:
+	case *MyIdent:
+	case fmt.Stringer:
+	}
+	if _, ok := x.(*ast.File); ok {
+	} else if _, ok := x.(*ast.File); ok {
+	} else if _, ok := x.(ast.Expr); ok {
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	var got []string
+	for _, err := range errs {
+		if assert.IsType(t, unreachableCaseError{}, err) {
+			got = append(got, err.Error())
+		}
+	}
+	assert.Equal(t, []string{
+		"case *ast.Ident for sum type 'Node' is shadowed by earlier case ast.Expr",
+		"case *MyIdent matches no variant of sum type 'Node'",
+		"case fmt.Stringer matches no variant of sum type 'Node'",
+		"case *ast.File for sum type 'Node' duplicates earlier case *ast.File",
+	}, got)
+}
+
+// TestGenericMissing tests that we detect missing variants of a generic sum
+// type, comparing case types with variants instantiated with the same type
+// arguments as the sum type.
+func TestGenericMissing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl Option
+
+type Option[T any] interface { isOption(T) }
+
+type Some[T any] struct { Value T }
+func (Some[T]) isOption(T) {}
+
+type None[T any] struct {}
+func (None[T]) isOption(T) {}
+
+func main() {
+	switch Option[int](nil).(type) {
+	case Some[int]:
+	}
+	switch Option[int](nil).(type) {
+	case Some[int], None[int]:
+	}
+}
+
+func generic[T any](o Option[T]) {
+	switch o.(type) {
+	case Some[T], None[T]:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"None"}, missingNames(t, errs[0]))
+}
+
+// TestGenericVariants tests that generic variants of a non-generic sum type
+// are found, and that any instantiation of them covers the variant.
+func TestGenericVariants(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A[X any] struct {}
+func (A[X]) sealed() {}
+
+type B[X, Y any] struct {}
+func (*B[X, Y]) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case A[int]:
+	}
+	switch T(nil).(type) {
+	case A[string], *B[int, int]:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+}
+
+// TestPanickingAssert tests that single-value type assertions on sum types are
+// reported when enabled, and that comma-ok type assertions are not.
+func TestPanickingAssert(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+func main() {
+	x := T(nil)
+	_ = x.(*A)
+	_, _ = x.(*A)
+	var _, _ = x.(*B)
+	if _, ok := (x.(*C)); ok {}
+	_ = x.(T)
+}
+`
+	reportAssertions = true
+	defer func() { reportAssertions = false }()
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	if !assert.IsType(t, panickingAssertError{}, errs[0]) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"B", "C"}, errs[0].(panickingAssertError).Names())
+}
+
+// TestIfChain tests that we detect missing variants in chains of if/else-if
+// statements whose conditions are comma-ok type assertions.
+func TestIfChain(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+func main() {
+	x := T(nil)
+	if _, ok := x.(*A); ok {
+	} else if _, ok := x.(*B); ok {
+	}
+
+	if _, ok := x.(*A); ok {
+	} else {
+		panic("unreachable")
+	}
+
+	if _, ok := x.(*A); ok {
+	} else if _, ok := x.(*B); ok {
+	} else if _, ok := x.(*C); ok {
+	}
+
+	if _, ok := x.(*A); ok {
+	} else {
+		println("legit catch all goes here")
+	}
+
+	if _, ok := x.(*A); ok {
+	}
+
+	if _, ok := x.(*A); ok {
+	} else if y := T(nil); y == nil {
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"C"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"B", "C"}, missingNames(t, errs[1]))
+}
+
+// TestEnumMissing tests that we detect missing constants in an expression
+// switch over an enum, even with a default case that panics, and that we
+// report a case that isn't one of the enum's constants.
+func TestEnumMissing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:enum Color
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+const NotAColor = 5
+
+func main() {
+	switch Color(0) {
+	case Red, NotAColor:
+	default:
+		panic("unreachable")
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Blue", "Green"}, missingNames(t, errs[0]))
+	assert.Equal(t, notVariant, errs[1].(unreachableCaseError).Reason)
+}
+
+// TestEnumNoMissing tests that constants are matched by value and that a
+// default case thwarts exhaustiveness checking of enums.
+func TestEnumNoMissing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:enum Color
+
+type Color string
+
+const (
+	Red Color = "red"
+	Green Color = "green"
+	Verde = Green
+)
+
+func main() {
+	switch Color("") {
+	case Red, "green":
+	}
+	switch Color("") {
+	case Red:
+	default:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestNotEnum tests that we report an error if one tries to declare an enum
+// whose type isn't a constant type.
+func TestNotEnum(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:enum T
+
+type T struct {}
+
+func main() {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "T", errs[0].(notEnumError).Decl.TypeName)
+}
+
+// TestLocalTypeShadowing tests that a type declared in a function isn't
+// mistaken for a package-level sum type with the same name.
+func TestLocalTypeShadowing(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	type T interface { String() string }
+	var v T
+	switch v.(type) {
+	case nil:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	assert.Len(t, errs, 0)
+}
+
+// TestNotSealed tests that we report an error if one tries to declare a sum
+// type with an unsealed interface.
+func TestNotSealed(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface {}
+
+func main() {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "T", errs[0].(unsealedError).Decl.TypeName)
+}
+
+// TestNotFound tests that we report an error if one tries to declare a sum
+// type that isn't defined.
+func TestNotFound(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+func main() {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "T", errs[0].(notFoundError).Decl.TypeName)
+}
+
+// TestNotInterface tests that we report an error if one tries to declare a sum
+// type that doesn't correspond to an interface.
+func TestNotInterface(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T struct {}
+
+func main() {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "T", errs[0].(notInterfaceError).Decl.TypeName)
+}
+
+func missingNames(t *testing.T, err error) []string {
+	if !assert.IsType(t, inexhaustiveError{}, err) {
+		t.FailNow()
+	}
+	return err.(inexhaustiveError).Names()
+}
+
+// TestUnsealedByEmbedding tests that exported types that get the seal of a
+// sum type by embedding are reported, along with (if enabled) types in other
+// packages that implement the sum type by embedding.
+func TestUnsealedByEmbedding(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Node
+
+type Node interface { node() }
+
+type Ident struct {}
+func (*Ident) node() {}
+
+type Base struct { Node }
+
+type Wrapper struct { Inner }
+
+type Inner struct { *Ident }
+
+type private struct { *Ident }
+`,
+		"main.go": `
+package main
+
+import "example.com/m/ast"
+
+type Leak struct {
+	ast.Wrapper
+}
+
+type Fine struct {}
+
+func main() {}
+`,
+	}
+	reportEmbedders = true
+	defer func() { reportEmbedders = false }()
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		"sum type 'Node' is unsealed by embedding: type 'Base' gets method 'node' through Base.Node",
+		"sum type 'Node' is unsealed by embedding: type 'Inner' gets method 'node' through Inner.Ident",
+		"sum type 'Node' is unsealed by embedding: type 'Wrapper' gets method 'node' through Wrapper.Inner.Ident",
+		"sum type 'Node' is unsealed by embedding: type 'Leak' gets method 'node' through Leak.Wrapper.Inner.Ident",
+	}, msgs)
+}
+
+// TestUnsealedByEmbeddingNonVariant tests that an exported type that gets
+// the seal of a sum type by embedding is reported in the declaring package
+// even if it isn't a variant, since other packages can embed it and add the
+// remaining methods to implement the sum type.
+func TestUnsealedByEmbeddingNonVariant(t *testing.T) {
+	code := `
+package ast
+
+//go-sumtype:decl Node
+
+type Node interface {
+	node()
+	Pos() int
+}
+
+type Ident struct {}
+func (*Ident) node() {}
+func (*Ident) Pos() int { return 0 }
+
+type base struct {}
+func (base) node() {}
+
+type Base struct { base }
+
+type Other struct {}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "sum type 'Node' is unsealed by embedding: type 'Base' gets "+
+		"method 'node' through Base.base", errs[0].Error())
+}
+
+// TestVariantList tests that discrepancies between the variants listed in a
+// declaration and the actual variants are reported, and that unlisted
+// variants are still variants.
+func TestVariantList(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T A B C
+
+type T interface { sealed() }
+
+type A struct {}
+func (*A) sealed() {}
+
+type B struct {}
+func (*B) sealed() {}
+
+type helper struct {}
+func (*helper) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A, *B:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t,
+		"'helper' is a variant of sum type 'T' but isn't listed in its declaration",
+		errs[0].Error())
+	assert.Equal(t,
+		"'C' is listed as a variant of sum type 'T' but isn't one",
+		errs[1].Error())
+	assert.Equal(t, []string{"helper"}, missingNames(t, errs[2]))
+}
+
+// TestAnnotations tests that ignore annotations suppress errors for case
+// analysis, that partial annotations acknowledge specific missing variants
+// and that annotations without an effect are reported.
+func TestAnnotations(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (*A) sealed() {}
+
+type B struct {}
+func (*B) sealed() {}
+
+type C struct {}
+func (*C) sealed() {}
+
+func main() {
+	x := T(nil)
+	//go-sumtype:ignore only A matters here
+	switch x.(type) {
+	case *A:
+	}
+	switch x.(type) { //go-sumtype:partial B
+	case *A:
+	}
+	//go-sumtype:partial B C
+	switch x.(type) {
+	case *A:
+	}
+	//go-sumtype:partial C
+	if _, ok := x.(*A); ok {
+	} else if _, ok := x.(*B); ok {
+	}
+	//go-sumtype:ignore
+	switch x.(type) {
+	case *A, *B, *C:
+	}
+	//go-sumtype:partial A D
+	switch x.(type) {
+	case *A, *B:
+	}
+	//go-sumtype:ignore
+	x = nil
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		"exhaustiveness check failed for sum type 'T': missing cases for C",
+		"go-sumtype:ignore annotation suppresses no errors for sum type 'T'",
+		"exhaustiveness check failed for sum type 'T': missing cases for C",
+		"variant 'A' named by go-sumtype:partial annotation is handled",
+		"'D' named by go-sumtype:partial annotation is not a variant of sum type 'T'",
+		"go-sumtype:ignore annotation is not on case analysis over a sum type",
+	}, msgs)
+}
@@ -0,0 +1,454 @@
+package sumtype
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is set by the analyzer's -config flag.
+var configFile string
+
+func init() {
+	Analyzer.Flags.StringVar(&configFile, "config", "",
+		"path to a configuration file (default .go-sumtype.yaml, .go-sumtype.yml "+
+			"or .go-sumtype.toml in the root of the main module)")
+}
+
+// configNames are the names of the configuration files that are discovered
+// in the root directory of the main module, in order of preference.
+var configNames = []string{".go-sumtype.yaml", ".go-sumtype.yml", ".go-sumtype.toml"}
+
+// config is the contents of a configuration file.
+type config struct {
+	// The path of the configuration file.
+	Path string `yaml:"-" toml:"-"`
+	// Sum types declared in the configuration file. They are treated the
+	// same as sum types declared with comments in the source of the
+	// package defining them.
+	SumTypes []configSumType `yaml:"sumtypes" toml:"sumtypes"`
+	// Paths, relative to the directory containing the configuration file,
+	// of files or directories in which no errors are reported. Paths may
+	// contain the wildcards understood by path.Match.
+	Exclude []string `yaml:"exclude" toml:"exclude"`
+}
+
+// configSumType is a sum type declared in a configuration file, along with
+// its options.
+type configSumType struct {
+	// The fully qualified name of the sum type, e.g.,
+	// `example.com/x/ast.Node`.
+	Type string `yaml:"type" toml:"type"`
+	// Whether the sum type is an enum rather than a sealed interface.
+	Enum bool `yaml:"enum" toml:"enum"`
+	// The intended variants of the sum type, if any. (See
+	// sumTypeDecl.Variants.)
+	Variants []string `yaml:"variants" toml:"variants"`
+	// Whether to report single-value type assertions on this sum type, as
+	// if -assertions were given.
+	Assertions bool `yaml:"assertions" toml:"assertions"`
+}
+
+// splitType returns the package path and name of the sum type.
+func (st configSumType) splitType() (pkgPath, name string, ok bool) {
+	i := strings.LastIndex(st.Type, ".")
+	if i <= 0 || i < strings.LastIndex(st.Type, "/") || i == len(st.Type)-1 {
+		return "", "", false
+	}
+	return st.Type[:i], st.Type[i+1:], true
+}
+
+var (
+	configMu sync.Mutex
+	// Configurations that have been loaded, keyed by path. A nil
+	// configuration records that no file exists at the path.
+	configs = make(map[string]*config)
+	// The roots of the main modules found, keyed by working directory.
+	// (See mainModuleRoot.)
+	mainModuleRoots = make(map[string]string)
+	// The module paths of the main modules found, keyed by their roots.
+	// (See mainModuleDir.)
+	mainModulePaths = make(map[string]string)
+)
+
+// loadConfig returns the configuration for the packages being analyzed. This
+// is the file given by -config, or else the first configuration file found
+// in the root of the main module. If there is no such file, then nil is
+// returned.
+//
+// The same configuration applies to every package, including dependencies
+// declared in other modules (e.g., the standard library or vendored code),
+// since declaring sum types for packages that can't be annotated is the
+// point of a configuration file.
+//
+// Configuration files are only read and validated once.
+func loadConfig() (*config, error) {
+	if configFile != "" {
+		return readConfig(configFile, true)
+	}
+	dir := mainModuleRoot()
+	if dir == "" {
+		return nil, nil
+	}
+	for _, name := range configNames {
+		cfg, err := readConfig(filepath.Join(dir, name), false)
+		if cfg != nil || err != nil {
+			return cfg, err
+		}
+	}
+	return nil, nil
+}
+
+// readConfig reads and validates the configuration file at the given path.
+// If the file doesn't exist and isn't required, then nil is returned.
+func readConfig(filename string, required bool) (*config, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	if cfg, ok := configs[filename]; ok {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) && !required {
+		configs[filename] = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cfg := &config{Path: filename}
+	if filepath.Ext(filename) == ".toml" {
+		err = decodeTOML(data, cfg)
+	} else {
+		err = decodeYAML(data, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	for _, st := range cfg.SumTypes {
+		if _, _, ok := st.splitType(); !ok {
+			return nil, fmt.Errorf(
+				"%s: sum type '%s' is not a fully qualified type name "+
+					"(e.g., example.com/x/ast.Node)", filename, st.Type)
+		}
+	}
+	for _, pattern := range cfg.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf(
+				"%s: invalid exclude pattern '%s': %s", filename, pattern, err)
+		}
+	}
+	configs[filename] = cfg
+	return cfg, nil
+}
+
+// decodeYAML decodes the given YAML configuration into cfg. Unknown fields are
+// rejected, since a misspelled field would otherwise silently be ignored.
+func decodeYAML(data []byte, cfg *config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// decodeTOML decodes the given TOML configuration into cfg. As with
+// decodeYAML, unknown keys are rejected.
+func decodeTOML(data []byte, cfg *config) error {
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = "'" + key.String() + "'"
+		}
+		return fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// mainModuleRoot returns the root directory of the main module, which is the
+// module containing the working directory. If the working directory isn't in
+// a module, then an empty string is returned.
+//
+// The root is only looked for once per working directory.
+func mainModuleRoot() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	root, ok := mainModuleRoots[wd]
+	if !ok {
+		root = moduleRoot(wd)
+		mainModuleRoots[wd] = root
+	}
+	return root
+}
+
+// moduleRoot returns the closest directory, starting at dir and moving up,
+// that contains a go.mod file. If there is no such directory, then an empty
+// string is returned.
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// sumTypeDecls returns a declaration for every sum type in this
+// configuration that is defined in the given package, with the given files,
+// unless it is also declared by one of the given declarations found in the
+// package's source.
+//
+// Since there is no comment to refer to, the declarations are positioned at
+// the package clause of the package's first file.
+func (cfg *config) sumTypeDecls(pkg *types.Package, files []*ast.File, found []sumTypeDecl) []sumTypeDecl {
+	if cfg == nil || len(files) == 0 {
+		return nil
+	}
+	declared := make(map[string]bool)
+	for _, decl := range found {
+		declared[decl.TypeName] = true
+	}
+	file := files[0]
+	var decls []sumTypeDecl
+	for _, st := range cfg.SumTypes {
+		pkgPath, name, _ := st.splitType()
+		if pkgPath != pkg.Path() || declared[name] {
+			continue
+		}
+		declared[name] = true
+		decl := sumTypeDecl{
+			Package:  pkg,
+			TypeName: name,
+			Variants: st.Variants,
+			Pos:      file.Package,
+			End:      file.Name.End(),
+		}
+		if st.Enum {
+			decl.Kind = declEnum
+		}
+		decls = append(decls, decl)
+	}
+	return decls
+}
+
+// configError corresponds to a sum type declared in the configuration file
+// for another package than the one being analyzed, which either doesn't
+// exist or has an invalid declaration. It is reported in every package
+// importing the declaring package, at the package clause of its first file,
+// since drivers only report the errors of the packages they're given.
+type configError struct {
+	Pos  token.Pos
+	End  token.Pos
+	Path string
+	// The sum type as it is given in the configuration file.
+	Type string
+	// The error for the declaration, as reported in the declaring package.
+	// This is nil if the declaring package doesn't exist.
+	Err error
+}
+
+func (e configError) Error() string {
+	if e.Err == nil {
+		pkgPath, _, _ := configSumType{Type: e.Type}.splitType()
+		return fmt.Sprintf("%s: sum type '%s': package '%s' does not exist",
+			e.Path, e.Type, pkgPath)
+	}
+	return fmt.Sprintf("%s: sum type '%s': %s", e.Path, e.Type, e.Err)
+}
+
+func (e configError) diagnostic() analysis.Diagnostic {
+	rule := RuleNotFound
+	if d, ok := e.Err.(diagnostic); ok {
+		rule = d.diagnostic().Category
+	}
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: rule,
+		Message:  e.Error(),
+	}
+}
+
+// importedErrors returns an error for every sum type in this configuration
+// that is declared for a package imported, directly or indirectly, by the
+// package being analyzed, and whose declaration is invalid. Sum types
+// declared for packages that aren't imported are only checked if they'd be
+// in the main module, in which case it's an error if their package doesn't
+// exist.
+//
+// Sum types declared for the package being analyzed are reported through
+// sumTypeDecls instead.
+func (cfg *config) importedErrors(pass *analysis.Pass) []error {
+	if cfg == nil || len(pass.Files) == 0 {
+		return nil
+	}
+	imported := make(map[string]*types.Package)
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		for _, imp := range pkg.Imports() {
+			if imported[imp.Path()] == nil {
+				imported[imp.Path()] = imp
+				visit(imp)
+			}
+		}
+	}
+	visit(pass.Pkg)
+
+	file := pass.Files[0]
+	var errs []error
+	for _, st := range cfg.SumTypes {
+		pkgPath, name, _ := st.splitType()
+		if pkgPath == pass.Pkg.Path() {
+			continue
+		}
+		report := func(err error) {
+			errs = append(errs, configError{
+				Pos:  file.Package,
+				End:  file.Name.End(),
+				Path: cfg.Path,
+				Type: st.Type,
+				Err:  err,
+			})
+		}
+		pkg := imported[pkgPath]
+		if pkg == nil {
+			if dir, ok := mainModuleDir(pkgPath); ok && !hasGoFiles(dir) {
+				report(nil)
+			}
+			continue
+		}
+		// Unexported types may be missing from packages imported from
+		// export data.
+		if !token.IsExported(name) && pkg.Scope().Lookup(name) == nil {
+			continue
+		}
+		decl := sumTypeDecl{
+			Package:  pkg,
+			TypeName: name,
+			Variants: st.Variants,
+			Pos:      file.Package,
+			End:      file.Name.End(),
+		}
+		if st.Enum {
+			decl.Kind = declEnum
+		}
+		if def, err := newSumTypeDef(pkg, decl); err != nil {
+			report(err)
+		} else if def == nil {
+			report(notFoundError{decl})
+		}
+	}
+	return errs
+}
+
+// mainModuleDir returns the directory that the package with the given import
+// path would have if it were in the main module. If it wouldn't be, then
+// false is returned.
+func mainModuleDir(pkgPath string) (string, bool) {
+	root := mainModuleRoot()
+	if root == "" {
+		return "", false
+	}
+	configMu.Lock()
+	modPath, ok := mainModulePaths[root]
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			modPath = modfile.ModulePath(data)
+		}
+		mainModulePaths[root] = modPath
+	}
+	configMu.Unlock()
+	if modPath == "" {
+		return "", false
+	}
+	if pkgPath == modPath {
+		return root, true
+	}
+	rel, ok := strings.CutPrefix(pkgPath, modPath+"/")
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(root, filepath.FromSlash(rel)), true
+}
+
+// hasGoFiles returns true if and only if the given directory contains a Go
+// source file.
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+// assertions returns true if and only if single-value type assertions on the
+// given sum type should be reported.
+func (cfg *config) assertions(def *sumTypeDef) bool {
+	if reportAssertions {
+		return true
+	}
+	if cfg == nil {
+		return false
+	}
+	for _, st := range cfg.SumTypes {
+		pkgPath, name, _ := st.splitType()
+		if st.Assertions && pkgPath == def.Decl.Package.Path() && name == def.Decl.TypeName {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded returns true if and only if no errors should be reported in the
+// file containing the given position.
+func (cfg *config) excluded(pass *analysis.Pass, pos token.Pos) bool {
+	if cfg == nil || len(cfg.Exclude) == 0 || !pos.IsValid() {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Dir(cfg.Path), pass.Fset.File(pos).Name())
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range cfg.Exclude {
+		pattern = strings.TrimSuffix(pattern, "/")
+		// A pattern excludes the files it matches and everything in the
+		// directories it matches.
+		for p := rel; p != "."; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package sumtype
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigDecl tests that sum types declared in a configuration file in
+// the root of the main module are checked, that invalid declarations are
+// reported in the declaring and importing packages and that nothing is
+// reported in excluded paths.
+func TestConfigDecl(t *testing.T) {
+	files := map[string]string{
+		".go-sumtype.yaml": `
+sumtypes:
+  - type: example.com/m/ast.Node
+  - type: example.com/m/ast.Unsealed
+exclude:
+  - gen
+`,
+		"ast/ast.go": `
+package ast
+
+type Node interface { node() }
+
+type Ident struct {}
+func (*Ident) node() {}
+
+type Lit struct {}
+func (*Lit) node() {}
+
+type Unsealed interface { Node() }
+`,
+		"main.go": `
+package main
+
+import "example.com/m/ast"
+
+func main() {
+	switch (ast.Node)(nil).(type) {
+	case *ast.Ident:
+	}
+}
+`,
+		"gen/gen.go": `
+package gen
+
+import "example.com/m/ast"
+
+func gen() {
+	switch (ast.Node)(nil).(type) {
+	case *ast.Ident:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+	defer chdir(t, tmpdir)()
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, "interface 'Unsealed' is not sealed "+
+		"(sealing requires at least one unexported method)", errs[0].Error())
+	// The importing package reports the invalid declaration too.
+	assert.Equal(t, filepath.Join(tmpdir, ".go-sumtype.yaml")+
+		": sum type 'example.com/m/ast.Unsealed': interface 'Unsealed' is not sealed "+
+		"(sealing requires at least one unexported method)", errs[1].Error())
+	assert.Equal(t, []string{"Lit"}, missingNames(t, errs[2]))
+}
+
+// TestConfigTOML tests that a TOML configuration file is discovered, and
+// that per-type options apply to the sum types they are given for.
+func TestConfigTOML(t *testing.T) {
+	files := map[string]string{
+		".go-sumtype.toml": `
+[[sumtypes]]
+type = "example.com/m.Color"
+enum = true
+
+[[sumtypes]]
+type = "example.com/m.T"
+assertions = true
+`,
+		"main.go": `
+package main
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+
+type T interface { sealed() }
+
+type A struct {}
+func (*A) sealed() {}
+
+type B struct {}
+func (*B) sealed() {}
+
+func main() {
+	switch Color(0) {
+	case Red:
+	}
+	_ = T(nil).(*A)
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+	defer chdir(t, tmpdir)()
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Green"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"B"}, errs[1].(panickingAssertError).Names())
+}
+
+// TestConfigOtherModule tests that the configuration file of the main module
+// applies to sum types declared in other modules, which have no
+// configuration file of their own.
+func TestConfigOtherModule(t *testing.T) {
+	files := map[string]string{
+		"go.mod": `
+module example.com/m
+
+go 1.22
+
+require example.com/dep v0.0.0
+
+replace example.com/dep => ./dep
+`,
+		".go-sumtype.yaml": `
+sumtypes:
+  - type: example.com/dep/ast.Node
+`,
+		"dep/go.mod": "module example.com/dep\n\ngo 1.22\n",
+		"dep/ast/ast.go": `
+package ast
+
+type Node interface { node() }
+
+type Ident struct {}
+func (*Ident) node() {}
+
+type Lit struct {}
+func (*Lit) node() {}
+`,
+		"main.go": `
+package main
+
+import "example.com/dep/ast"
+
+func main() {
+	switch (ast.Node)(nil).(type) {
+	case *ast.Ident:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+	defer chdir(t, tmpdir)()
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Lit"}, missingNames(t, errs[0]))
+}
+
+// TestConfigUnknownFields tests that configuration files with unknown fields,
+// e.g., because of a typo, are rejected rather than silently ignored, and
+// that empty configuration files are accepted.
+func TestConfigUnknownFields(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "go-test-sumtype-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	tests := []struct {
+		name, data, err string
+	}{
+		{"empty.yaml", "", ""},
+		{"empty.toml", "", ""},
+		{
+			"typo.yaml",
+			"sumtype:\n  - type: example.com/m.T\n",
+			"yaml: unmarshal errors:\n  line 1: field sumtype not found in type sumtype.config",
+		},
+		{
+			"nested.yaml",
+			"sumtypes:\n  - type: example.com/m.T\n    assertion: true\n",
+			"yaml: unmarshal errors:\n  line 3: field assertion not found in type sumtype.configSumType",
+		},
+		{
+			"typo.toml",
+			"[[sumtype]]\ntype = \"example.com/m.T\"\n",
+			"unknown keys 'sumtype', 'sumtype.type'",
+		},
+		{
+			"nested.toml",
+			"[[sumtypes]]\ntype = \"example.com/m.T\"\nassertion = true\n",
+			"unknown keys 'sumtypes.assertion'",
+		},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(tmpdir, test.name)
+		if err := os.WriteFile(filename, []byte(test.data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := readConfig(filename, true)
+		if test.err == "" {
+			assert.NoError(t, err, test.name)
+			assert.NotNil(t, cfg, test.name)
+		} else {
+			assert.EqualError(t, err, filename+": "+test.err, test.name)
+		}
+	}
+}
+
+// TestConfigImportedErrors tests that invalid declarations in the
+// configuration file are reported in the packages importing the declaring
+// package, and that declarations for packages of the main module that don't
+// exist are reported in every package.
+func TestConfigImportedErrors(t *testing.T) {
+	files := map[string]string{
+		".go-sumtype.yaml": `
+sumtypes:
+  - type: example.com/m/a.Nope
+  - type: example.com/m/a.Color
+  - type: example.com/m/a.Node
+  - type: example.com/m/zzz.Node
+  - type: example.com/other.Node
+`,
+		"a/a.go": `
+package a
+
+type Color int
+
+type Node interface { node() }
+
+type Lit int
+func (Lit) node() {}
+`,
+		"b/b.go": `
+package b
+
+import "example.com/m/a"
+
+func F(n a.Node) {
+	switch n.(type) {
+	case a.Lit:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./b")
+	defer teardownPackage(t, tmpdir)
+	defer chdir(t, tmpdir)()
+
+	errs := runAnalyzer(t, pkgs)
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	path := filepath.Join(tmpdir, ".go-sumtype.yaml")
+	assert.Equal(t, []string{
+		path + ": sum type 'example.com/m/a.Nope': type 'Nope' is not defined",
+		path + ": sum type 'example.com/m/a.Color': type 'Color' is not an interface",
+		path + ": sum type 'example.com/m/zzz.Node': package 'example.com/m/zzz' does not exist",
+	}, got)
+	if assert.Len(t, errs, 3) {
+		f, ok := NewFinding(errs[0])
+		assert.True(t, ok)
+		assert.Equal(t, RuleNotFound, f.Rule)
+		assert.Equal(t, "Nope", f.SumType)
+		assert.Equal(t, 2, pkgs[0].Fset.Position(f.Pos).Line)
+	}
+}
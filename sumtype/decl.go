@@ -0,0 +1,114 @@
+package sumtype
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// declKind distinguishes between the kinds of sum types that may be declared.
+type declKind int
+
+const (
+	// declInterface is a sealed interface declared with
+	// `go-sumtype:decl`, whose variants are the types implementing it.
+	declInterface declKind = iota
+	// declEnum is a named constant type declared with `go-sumtype:enum`,
+	// whose variants are the package level constants of that type.
+	declEnum
+)
+
+// sumTypeDecl is a declaration of a sum type in a Go source file.
+type sumTypeDecl struct {
+	// The package that contains this decl.
+	Package *types.Package
+	// The type named by this decl.
+	TypeName string
+	// The kind of sum type declared.
+	Kind declKind
+	// The names of the variants listed by this decl, if any. When present,
+	// they are the intended set of variants, and any discrepancy with the
+	// actual set of variants is reported.
+	Variants []string
+	// The position at which this declaration was found.
+	Pos token.Pos
+	// The end of the declaration, if it was found in a comment.
+	End token.Pos
+}
+
+// findSumTypeDecls searches the comments of the given files, which make up the
+// given package, for sum type declarations of the form
+// `//go-sumtype:decl ...` or `//go-sumtype:enum ...`. A declaration may list
+// the variants of the sum type after its name, e.g.,
+// `//go-sumtype:decl T A B C`.
+//
+// Only line comments are considered, but they may appear anywhere in a file,
+// e.g., indented in a type's doc comment or inside a grouped type
+// declaration.
+func findSumTypeDecls(pkg *types.Package, files []*ast.File) []sumTypeDecl {
+	var decls []sumTypeDecl
+	for _, file := range files {
+		decls = append(decls, sumTypeDeclSearch(pkg, file)...)
+	}
+	return decls
+}
+
+// sumTypeDeclSearch searches the comments in the given file for sum type
+// declarations of the form `//go-sumtype:decl ...` or
+// `//go-sumtype:enum ...`.
+func sumTypeDeclSearch(pkg *types.Package, file *ast.File) []sumTypeDecl {
+	var decls []sumTypeDecl
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if !isSumTypeDecl(c.Text) {
+				continue
+			}
+			kind, ty, variants := parseSumTypeDecl(c.Text)
+			if len(ty) == 0 {
+				continue
+			}
+			decls = append(decls, sumTypeDecl{
+				Package:  pkg,
+				TypeName: ty,
+				Kind:     kind,
+				Variants: variants,
+				Pos:      c.Pos(),
+				End:      c.End(),
+			})
+		}
+	}
+	return decls
+}
+
+var reParseSumTypeDecl = regexp.MustCompile(
+	`^//go-sumtype:(decl|enum)\s+(\S+)((?:\s+\S+)*)\s*$`)
+
+// parseSumTypeDecl parses the kind, type name and listed variants (if any)
+// out of a sum type decl.
+//
+// If no such decl could be found, then this returns an empty type name.
+func parseSumTypeDecl(comment string) (declKind, string, []string) {
+	caps := reParseSumTypeDecl.FindStringSubmatch(comment)
+	if len(caps) < 4 {
+		return declInterface, "", nil
+	}
+	variants := strings.Fields(caps[3])
+	if caps[1] == "enum" {
+		return declEnum, caps[2], variants
+	}
+	return declInterface, caps[2], variants
+}
+
+// isSumTypeDecl returns true if and only if this comment in a Go source file
+// is a sum type decl.
+func isSumTypeDecl(comment string) bool {
+	for _, prefix := range []string{"//go-sumtype:decl", "//go-sumtype:enum"} {
+		if strings.HasPrefix(comment, prefix+" ") ||
+			strings.HasPrefix(comment, prefix+"\t") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package sumtype
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeclSearch tests that sum type declarations are found wherever a line
+// comment may appear, and nowhere else.
+func TestDeclSearch(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl A
+
+// B is a sum type.
+//
+//go-sumtype:decl B
+type B interface { sealed() }
+
+type (
+	//go-sumtype:decl C
+	C interface { sealed() }
+)
+
+func main() {
+	//go-sumtype:decl D
+	_ = "//go-sumtype:decl NotAString"
+	_ = "` + strings.Repeat("x", 128*1024) + `"
+	/*
+	//go-sumtype:decl NotABlock
+	*/
+}
+
+//go-sumtype:decl	E
+//go-sumtype:declF
+//go-sumtype:decl G H
+//go-sumtype:enum I J  K
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "src.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var enums []string
+	variants := make(map[string][]string)
+	for _, decl := range sumTypeDeclSearch(nil, file) {
+		names = append(names, decl.TypeName)
+		if decl.Kind == declEnum {
+			enums = append(enums, decl.TypeName)
+		}
+		if len(decl.Variants) > 0 {
+			variants[decl.TypeName] = decl.Variants
+		}
+	}
+	assert.Equal(t, []string{"A", "B", "C", "D", "E", "G", "I"}, names)
+	assert.Equal(t, []string{"I"}, enums)
+	assert.Equal(t, map[string][]string{"G": {"H"}, "I": {"J", "K"}}, variants)
+}
@@ -0,0 +1,453 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// unsealedError corresponds to a declared sum type whose interface is not
+// sealed. A sealed interface requires at least one unexported method.
+type unsealedError struct {
+	Decl sumTypeDecl
+}
+
+func (e unsealedError) Error() string {
+	return fmt.Sprintf(
+		"interface '%s' is not sealed "+
+			"(sealing requires at least one unexported method)",
+		e.Decl.TypeName)
+}
+
+func (e unsealedError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Decl.Pos,
+		End:      e.Decl.End,
+		Category: RuleUnsealed,
+		Message:  e.Error(),
+	}
+}
+
+// notFoundError corresponds to a declared sum type whose type definition
+// could not be found in the same Go package.
+type notFoundError struct {
+	Decl sumTypeDecl
+}
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("type '%s' is not defined", e.Decl.TypeName)
+}
+
+func (e notFoundError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Decl.Pos,
+		End:      e.Decl.End,
+		Category: RuleNotFound,
+		Message:  e.Error(),
+	}
+}
+
+// notInterfaceError corresponds to a declared sum type that does not
+// correspond to an interface.
+type notInterfaceError struct {
+	Decl sumTypeDecl
+}
+
+func (e notInterfaceError) Error() string {
+	return fmt.Sprintf("type '%s' is not an interface", e.Decl.TypeName)
+}
+
+func (e notInterfaceError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Decl.Pos,
+		End:      e.Decl.End,
+		Category: RuleNotInterface,
+		Message:  e.Error(),
+	}
+}
+
+// variantListError corresponds to a discrepancy between the variants listed
+// in a sum type declaration and the actual variants of the sum type.
+type variantListError struct {
+	Pos token.Pos
+	End token.Pos
+	Def sumTypeDef
+	// The name of the variant.
+	Name string
+	// Whether the variant is an actual variant that isn't listed. Otherwise,
+	// it is listed but isn't a variant.
+	Unlisted bool
+}
+
+func (e variantListError) Error() string {
+	if e.Unlisted {
+		return fmt.Sprintf(
+			"'%s' is a variant of sum type '%s' but isn't listed in its declaration",
+			e.Name, e.Def.Decl.TypeName)
+	}
+	return fmt.Sprintf(
+		"'%s' is listed as a variant of sum type '%s' but isn't one",
+		e.Name, e.Def.Decl.TypeName)
+}
+
+func (e variantListError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RuleVariantList,
+		Message:  e.Error(),
+	}
+}
+
+// sumTypeDef corresponds to the definition of a Go interface that is
+// interpreted as a sum type. Its variants are determined by finding all types
+// that implement said interface in the same package.
+//
+// A sumTypeDef may also correspond to an enum, in which case Ty is nil and
+// its variants are constants. (See newEnumDef.)
+type sumTypeDef struct {
+	Decl     sumTypeDecl
+	Obj      *types.TypeName
+	Ty       *types.Interface
+	Variants []types.Object
+	// The index of the variants, built when it's first needed, if this
+	// is an interface. (See variants.)
+	index *variantIndexOnce
+}
+
+// findSumTypeDefs attempts to find a Go type definition for each of the given
+// sum type declarations. If no such sum type definition could be found for
+// any of the given declarations, then an error is returned.
+func findSumTypeDefs(decls []sumTypeDecl) ([]sumTypeDef, []error) {
+	var defs []sumTypeDef
+	var errs []error
+	for _, decl := range decls {
+		def, err := newSumTypeDef(decl.Package, decl)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if def == nil {
+			errs = append(errs, notFoundError{decl})
+			continue
+		}
+		defs = append(defs, *def)
+		errs = append(errs, def.checkVariantList()...)
+	}
+	return defs, errs
+}
+
+// checkVariantList returns an error for every variant of this sum type that
+// isn't listed in its declaration, and for every listed variant that isn't a
+// variant, if its declaration lists variants.
+//
+// Unlisted variants are still variants: they must be handled by case analysis
+// over the sum type like any other.
+func (def *sumTypeDef) checkVariantList() []error {
+	if len(def.Decl.Variants) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool)
+	for _, name := range def.Decl.Variants {
+		listed[name] = true
+	}
+	var errs []error
+	actual := make(map[string]bool)
+	for _, v := range def.Variants {
+		actual[v.Name()] = true
+		if !listed[v.Name()] {
+			errs = append(errs, variantListError{
+				Pos:      v.Pos(),
+				End:      v.Pos() + token.Pos(len(v.Name())),
+				Def:      *def,
+				Name:     v.Name(),
+				Unlisted: true,
+			})
+		}
+	}
+	for _, name := range def.Decl.Variants {
+		if !actual[name] {
+			errs = append(errs, variantListError{
+				Pos:  def.Decl.Pos,
+				End:  def.Decl.End,
+				Def:  *def,
+				Name: name,
+			})
+		}
+	}
+	return errs
+}
+
+// newSumTypeDef attempts to extract a sum type definition from a single
+// package. If no such type corresponds to the given decl, then this function
+// returns a nil def and a nil error.
+//
+// If the decl corresponds to a type that isn't an interface containing at
+// least one unexported method, then this returns an error.
+func newSumTypeDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
+	obj, ok := pkg.Scope().Lookup(decl.TypeName).(*types.TypeName)
+	if !ok {
+		return nil, nil
+	}
+	if decl.Kind == declEnum {
+		return newEnumDef(pkg, decl, obj)
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, notInterfaceError{decl}
+	}
+	hasUnexported := false
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() {
+			hasUnexported = true
+			break
+		}
+	}
+	if !hasUnexported {
+		return nil, unsealedError{decl}
+	}
+	def := &sumTypeDef{
+		Decl:  decl,
+		Obj:   obj,
+		Ty:    iface,
+		index: new(variantIndexOnce),
+	}
+	var tparams *types.TypeParamList
+	if named, ok := types.Unalias(obj.Type()).(*types.Named); ok {
+		tparams = named.TypeParams()
+	}
+	for _, name := range pkg.Scope().Names() {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		ty := obj.Type()
+		if types.Identical(ty.Underlying(), iface) {
+			continue
+		}
+		if implements(ty, tparams, iface) {
+			def.Variants = append(def.Variants, obj)
+		}
+	}
+	return def, nil
+}
+
+// withoutTestVariants returns the given definitions with the variants
+// declared in test files removed. Such variants only exist in the test
+// variant of their package, so the package's other files can't have cases
+// for them. If no definition has such variants, then nil is returned.
+func withoutTestVariants(fset *token.FileSet, defs []sumTypeDef) []sumTypeDef {
+	isTest := func(v types.Object) bool {
+		return strings.HasSuffix(fset.Position(v.Pos()).Filename, "_test.go")
+	}
+	found := false
+	for _, def := range defs {
+		for _, v := range def.Variants {
+			found = found || isTest(v)
+		}
+	}
+	if !found {
+		return nil
+	}
+	stripped := make([]sumTypeDef, len(defs))
+	for i, def := range defs {
+		var variants []types.Object
+		for _, v := range def.Variants {
+			if !isTest(v) {
+				variants = append(variants, v)
+			}
+		}
+		if len(variants) < len(def.Variants) {
+			def.Variants = variants
+			if def.index != nil {
+				def.index = new(variantIndexOnce)
+			}
+		}
+		stripped[i] = def
+	}
+	return stripped
+}
+
+func (def *sumTypeDef) String() string {
+	return def.Decl.TypeName
+}
+
+// missing returns a list of variants in this sum type that are not covered by
+// the given list of types. sumTy is the type of the value being matched,
+// which is an instantiation of this sum type when it is generic. In that
+// case, the variants are instantiated with the same type arguments before
+// comparing them with the given types.
+//
+// A variant is covered by a type that is the same as the variant, or by an
+// interface type that the variant's values implement. (If the variant only
+// implements the sum type through a pointer, then its values are pointers.
+// Otherwise, they have only the variant's own methods, and not those with
+// pointer receivers.) A variant that is itself an
+// interface (i.e., a nested sum type) is also covered when every concrete
+// variant implementing it is covered.
+//
+// When none of the concrete variants of a nested sum type are covered, then
+// the nested sum type is reported as missing in place of its variants.
+func (def *sumTypeDef) missing(sumTy types.Type, tys []types.Type) []types.Object {
+	// This takes time linear in the number of variants and cases, except
+	// for interface cases and nested sum types, which are checked against
+	// every variant.
+	vi := def.variants(sumTy)
+	sumIface, _ := sumTy.Underlying().(*types.Interface)
+	covered := make([]bool, len(def.Variants))
+	for _, ty := range tys {
+		for _, i := range vi.byType.lookup(indirect(ty)) {
+			covered[i] = true
+		}
+		iface, ok := ty.Underlying().(*types.Interface)
+		if !ok || sumIface == nil {
+			continue
+		}
+		// An interface case only covers the variants whose values
+		// implement it, not those that only implement it through a
+		// pointer to them.
+		for i, varty := range vi.types {
+			if valty := valueType(varty, sumIface); valty != nil && types.Implements(valty, iface) {
+				covered[i] = true
+			}
+		}
+	}
+
+	// wholly[i] is true when the variant at index i is a nested sum type
+	// none of whose concrete variants are covered.
+	wholly := make([]bool, len(def.Variants))
+	for i, mems := range vi.members {
+		if covered[i] || len(mems) == 0 {
+			continue
+		}
+		all, none := true, true
+		for _, j := range mems {
+			all = all && covered[j]
+			none = none && !covered[j]
+		}
+		covered[i] = all
+		wholly[i] = none
+	}
+
+	var missing []types.Object
+	for i, v := range def.Variants {
+		if covered[i] {
+			continue
+		}
+		if len(vi.members[i]) > 0 && !wholly[i] {
+			// Its uncovered members are reported instead.
+			continue
+		}
+		if inWhollyMissing(i, vi, wholly) {
+			continue
+		}
+		missing = append(missing, v)
+	}
+	return missing
+}
+
+// inWhollyMissing returns true if and only if the variant at index i is part
+// of a different nested sum type that is reported as missing in its entirety.
+func inWhollyMissing(i int, vi *variantIndex, wholly []bool) bool {
+	for _, j := range vi.containers[i] {
+		if !wholly[j] {
+			continue
+		}
+		// Nested sum types with identical method sets implement each
+		// other, in which case only the first one is reported.
+		if wholly[i] && j > i && contains(vi.containers[j], i) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// sameType returns true if and only if the given types are identical or are
+// both named types with the same name declared in packages with the same
+// path (and, if instantiated, with the same type arguments).
+//
+// As a special case, an uninstantiated generic type is the same as any
+// instantiation of it. This is used when the type arguments of a variant
+// can't be inferred from those of its sum type.
+//
+// The latter permits types from distinct copies of the same package to be
+// matched with one another. For example, when test files are included, a
+// package and its test variant are type checked separately and therefore
+// define distinct (but otherwise equivalent) types.
+func sameType(x, y types.Type) bool {
+	if types.Identical(x, y) {
+		return true
+	}
+	nx, ok := x.(*types.Named)
+	if !ok {
+		return false
+	}
+	ny, ok := y.(*types.Named)
+	if !ok {
+		return false
+	}
+	if !sameTypeName(nx.Obj(), ny.Obj()) {
+		return false
+	}
+	if isGeneric(nx) || isGeneric(ny) {
+		return true
+	}
+	xargs, yargs := nx.TypeArgs(), ny.TypeArgs()
+	if xargs.Len() != yargs.Len() {
+		return false
+	}
+	for i := 0; i < xargs.Len(); i++ {
+		if !sameType(xargs.At(i), yargs.At(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTypeName returns true if and only if the given type names have the same
+// name and are declared in packages with the same path.
+//
+// Type names declared in functions are only the same as themselves, since a
+// local type may shadow a package-level type with the same name.
+func sameTypeName(x, y *types.TypeName) bool {
+	if x.Name() != y.Name() {
+		return false
+	}
+	if isLocal(x) || isLocal(y) {
+		return x == y
+	}
+	if x.Pkg() == nil || y.Pkg() == nil {
+		return x.Pkg() == y.Pkg()
+	}
+	return x.Pkg().Path() == y.Pkg().Path()
+}
+
+// isLocal returns true if and only if the given type name is declared in a
+// function rather than at package level.
+//
+// Type names that aren't in any scope, such as those made up for variants
+// missing from export data (see sumTypeDefFromFact), are package-level.
+func isLocal(tn *types.TypeName) bool {
+	return tn.Pkg() != nil && tn.Parent() != nil && tn.Parent() != tn.Pkg().Scope()
+}
+
+// indirect dereferences through an arbitrary number of pointer types.
+func indirect(ty types.Type) types.Type {
+	if ty, ok := ty.(*types.Pointer); ok {
+		return indirect(ty.Elem())
+	}
+	return ty
+}
+
+func pkgFiles(pkg *ast.Package) []*ast.File {
+	var files []*ast.File
+	for _, file := range pkg.Files {
+		files = append(files, file)
+	}
+	return files
+}
@@ -0,0 +1,13 @@
+/*
+Package sumtype provides an analyzer that performs exhaustiveness checks on
+type switch statements over interfaces that are declared to be sum types.
+
+Declarations are provided in comments like so:
+
+	//go-sumtype:decl MySumType
+
+See the go-sumtype command for more details. The Analyzer defined here may be
+used with any driver built on golang.org/x/tools/go/analysis, e.g.,
+multichecker, unitchecker or `go vet -vettool`.
+*/
+package sumtype
@@ -0,0 +1,158 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// reportEmbedders is set by the analyzer's -embedding flag.
+var reportEmbedders bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&reportEmbedders, "embedding", false,
+		"report types in importing packages that implement sum types by embedding")
+}
+
+// unsealedByEmbeddingError is returned for each type through which the seal
+// of a sum type (one of its unexported methods) is promoted by embedding.
+//
+// In the package declaring a sum type, an exported type that gets the seal by
+// embedding breaks it, whether or not it's a variant: any other package can
+// embed it in turn, along with any other methods of the sum type, to
+// implement the sum type. In other packages, every type implementing the sum
+// type does so by embedding.
+type unsealedByEmbeddingError struct {
+	Pos token.Pos
+	End token.Pos
+	Def sumTypeDef
+	// The type through which the seal is promoted.
+	Type *types.TypeName
+	// The name of the promoted seal method.
+	Method string
+	// The embedded fields through which the method is promoted, starting
+	// with a field of Type.
+	Path []string
+}
+
+func (e unsealedByEmbeddingError) Error() string {
+	return fmt.Sprintf(
+		"sum type '%s' is unsealed by embedding: type '%s' gets method "+
+			"'%s' through %s",
+		e.Def.Decl.TypeName, e.Type.Name(), e.Method,
+		strings.Join(append([]string{e.Type.Name()}, e.Path...), "."))
+}
+
+func (e unsealedByEmbeddingError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RuleUnsealedByEmbedding,
+		Message:  e.Error(),
+	}
+}
+
+// checkEmbedding returns an error for every exported type, declared in the
+// same package as one of the given sum types, that gets the seal of the sum
+// type by embedding. The sum types must all be declared in the package being
+// analyzed.
+//
+// Such a type need not implement the sum type (e.g., it may lack one of its
+// exported methods) to unseal it.
+func checkEmbedding(defs []sumTypeDef) []error {
+	var errs []error
+	for i := range defs {
+		def := &defs[i]
+		seal := def.seal()
+		if seal == nil {
+			continue
+		}
+		scope := def.Decl.Package.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !tn.Exported() || tn.IsAlias() || types.IsInterface(tn.Type()) {
+				continue
+			}
+			if err, ok := def.embedsSeal(tn, seal); ok {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// checkEmbedders returns an error for every type declared at the package
+// level of the package being analyzed that implements one of the given sum
+// types, all of which must be declared in other packages. Such a type can
+// only implement a sum type by embedding.
+func checkEmbedders(pass *analysis.Pass, defs []sumTypeDef) []error {
+	var errs []error
+	scope := pass.Pkg.Scope()
+	for i := range defs {
+		def := &defs[i]
+		seal := def.seal()
+		if seal == nil {
+			continue
+		}
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() || types.IsInterface(tn.Type()) {
+				continue
+			}
+			// Methods of a generic sum type mention its type parameters,
+			// so only check that the seal is present.
+			if !isGeneric(def.Obj.Type()) && !implements(tn.Type(), nil, def.Ty) {
+				continue
+			}
+			if err, ok := def.embedsSeal(tn, seal); ok {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// seal returns one of the unexported methods of this sum type, or nil if it
+// isn't an interface.
+func (def *sumTypeDef) seal() *types.Func {
+	if def.Ty == nil {
+		return nil
+	}
+	for i := 0; i < def.Ty.NumMethods(); i++ {
+		if m := def.Ty.Method(i); !m.Exported() {
+			return m
+		}
+	}
+	return nil
+}
+
+// embedsSeal returns an error if the given type gets the given seal method
+// of this sum type through an embedded field.
+func (def *sumTypeDef) embedsSeal(
+	tn *types.TypeName,
+	seal *types.Func,
+) (unsealedByEmbeddingError, bool) {
+	obj, index, _ := types.LookupFieldOrMethod(tn.Type(), true, seal.Pkg(), seal.Name())
+	if _, ok := obj.(*types.Func); !ok || len(index) < 2 {
+		return unsealedByEmbeddingError{}, false
+	}
+	err := unsealedByEmbeddingError{Def: *def, Type: tn, Method: seal.Name()}
+	ty := tn.Type()
+	for _, i := range index[:len(index)-1] {
+		st, ok := indirect(ty).Underlying().(*types.Struct)
+		if !ok {
+			return unsealedByEmbeddingError{}, false
+		}
+		field := st.Field(i)
+		if !err.Pos.IsValid() {
+			err.Pos = field.Pos()
+			err.End = field.Pos() + token.Pos(len(field.Name()))
+		}
+		err.Path = append(err.Path, field.Name())
+		ty = field.Type()
+	}
+	return err, true
+}
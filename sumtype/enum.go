@@ -0,0 +1,123 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// notEnumError corresponds to a declared enum whose type is not a constant
+// type. That is, its underlying type is not a boolean, numeric or string type.
+type notEnumError struct {
+	Decl sumTypeDecl
+}
+
+func (e notEnumError) Error() string {
+	return fmt.Sprintf(
+		"type '%s' is not an enum "+
+			"(enums require a boolean, numeric or string underlying type)",
+		e.Decl.TypeName)
+}
+
+func (e notEnumError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Decl.Pos,
+		End:      e.Decl.End,
+		Category: RuleNotEnum,
+		Message:  e.Error(),
+	}
+}
+
+// newEnumDef extracts an enum definition for the given named type, which was
+// declared as an enum by decl. The variants of an enum are all of the package
+// level constants whose type is the enum's type.
+//
+// If the given type isn't a constant type, then this returns an error.
+func newEnumDef(
+	pkg *types.Package,
+	decl sumTypeDecl,
+	obj *types.TypeName,
+) (*sumTypeDef, error) {
+	basic, ok := obj.Type().Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsConstType == 0 {
+		return nil, notEnumError{decl}
+	}
+	def := &sumTypeDef{
+		Decl: decl,
+		Obj:  obj,
+	}
+	for _, name := range pkg.Scope().Names() {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok {
+			continue
+		}
+		if types.Identical(c.Type(), obj.Type()) {
+			def.Variants = append(def.Variants, c)
+		}
+	}
+	return def, nil
+}
+
+// missingValues returns a list of constants in this enum whose values are not
+// in the given list of values.
+func (def *sumTypeDef) missingValues(vals []constant.Value) []types.Object {
+	covered := make(map[string]bool, len(vals))
+	for _, val := range vals {
+		covered[val.ExactString()] = true
+	}
+	var missing []types.Object
+	for _, v := range def.Variants {
+		val := v.(*types.Const).Val()
+		if val.Kind() == constant.Unknown || !covered[val.ExactString()] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// checkEnumSwitch performs an exhaustiveness check on the given expression
+// switch statement. If the switch's tag is a declared enum and the switch
+// does not cover the value of every constant in that enum, then an error is
+// returned indicating which constants were missed.
+//
+// As with type switches, a default case that may complete normally disables
+// exhaustiveness checks.
+func checkEnumSwitch(
+	pass *analysis.Pass,
+	idx *defIndex,
+	swtch *ast.SwitchStmt,
+	ann *annotation,
+) error {
+	if swtch.Tag == nil {
+		return nil
+	}
+	def := idx.findEnum(pass.TypesInfo.TypeOf(swtch.Tag))
+	if def == nil {
+		return nil
+	}
+	ann.acknowledge(def, nil)
+	exprs, hasDefault := switchVariants(swtch.Body)
+	if hasDefault && !defaultClauseNeverCompletes(pass, swtch.Body) {
+		return nil
+	}
+	var vals []constant.Value
+	for _, expr := range exprs {
+		if val := pass.TypesInfo.Types[expr].Value; val != nil {
+			vals = append(vals, val)
+		}
+	}
+	if missing := ann.acknowledge(def, def.missingValues(vals)); len(missing) > 0 {
+		ty := pass.TypesInfo.TypeOf(swtch.Tag)
+		return inexhaustiveError{
+			Pos:     swtch.Pos(),
+			End:     swtch.Body.Lbrace,
+			Def:     *def,
+			Missing: missing,
+			Fixes:   suggestMissingCases(pass, def, ty, swtch, swtch.Body, missing),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package sumtype
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// sumTypeFact is exported for the type name of every valid sum type
+// declaration. It permits packages that import the declaring package to
+// check type switches over the sum type without access to the declaring
+// package's source.
+type sumTypeFact struct {
+	// Whether the sum type is an enum, in which case its variants are
+	// constants.
+	Enum bool
+	// The names of the variants of the sum type. Every variant is defined
+	// in the same package as the sum type itself.
+	Variants []string
+}
+
+func (*sumTypeFact) AFact() {}
+
+func (f *sumTypeFact) String() string {
+	return "sumtype"
+}
+
+// exportSumTypeFacts exports a fact for each of the given sum type
+// definitions, all of which must be declared in the package being analyzed.
+func exportSumTypeFacts(pass *analysis.Pass, defs []sumTypeDef) {
+	for _, def := range defs {
+		fact := &sumTypeFact{Enum: def.Decl.Kind == declEnum}
+		for _, v := range def.Variants {
+			fact.Variants = append(fact.Variants, v.Name())
+		}
+		sort.Strings(fact.Variants)
+		pass.ExportObjectFact(def.Obj, fact)
+	}
+}
+
+// importSumTypeDefs returns a sum type definition for every sum type declared
+// in a package imported (directly or transitively) by the package being
+// analyzed.
+func importSumTypeDefs(pass *analysis.Pass) []sumTypeDef {
+	var defs []sumTypeDef
+	for _, f := range pass.AllObjectFacts() {
+		fact, ok := f.Fact.(*sumTypeFact)
+		if !ok {
+			continue
+		}
+		obj, ok := f.Object.(*types.TypeName)
+		if !ok || obj.Pkg() == pass.Pkg {
+			continue
+		}
+		if def := sumTypeDefFromFact(obj, fact); def != nil {
+			defs = append(defs, *def)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Obj.Id() < defs[j].Obj.Id()
+	})
+	return defs
+}
+
+// sumTypeDefFromFact reconstructs the definition of an imported sum type from
+// the fact exported by its declaring package.
+func sumTypeDefFromFact(obj *types.TypeName, fact *sumTypeFact) *sumTypeDef {
+	def := &sumTypeDef{
+		Decl: sumTypeDecl{
+			Package:  obj.Pkg(),
+			TypeName: obj.Name(),
+			Pos:      obj.Pos(),
+		},
+		Obj: obj,
+	}
+	if fact.Enum {
+		def.Decl.Kind = declEnum
+		for _, name := range fact.Variants {
+			c, ok := obj.Pkg().Scope().Lookup(name).(*types.Const)
+			if !ok {
+				// As with interface variants below, an unexported
+				// constant may be absent from export data. Its value
+				// is unknown, so it is never considered covered.
+				c = types.NewConst(
+					token.NoPos, obj.Pkg(), name, obj.Type(),
+					constant.MakeUnknown())
+			}
+			def.Variants = append(def.Variants, c)
+		}
+		return def
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	def.Ty = iface
+	def.index = new(variantIndexOnce)
+	for _, name := range fact.Variants {
+		v, ok := obj.Pkg().Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			// Export data only includes unexported types that are
+			// reachable from exported declarations, so a variant may be
+			// absent from the importer's view of the package. Such a
+			// variant cannot be named in a case clause, but it is still
+			// a variant, so we represent it with a type that is not
+			// identical to any other.
+			v = types.NewTypeName(token.NoPos, obj.Pkg(), name, nil)
+			types.NewNamed(v, types.NewStruct(nil, nil), nil)
+		}
+		def.Variants = append(def.Variants, v)
+	}
+	return def
+}
@@ -0,0 +1,102 @@
+package sumtype
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Stable identifiers for each kind of error reported by Analyzer. They are
+// used as the category of the analyzer's diagnostics.
+const (
+	RuleInexhaustive        = "inexhaustive"
+	RuleUnsealed            = "unsealed"
+	RuleUnsealedByEmbedding = "unsealed-by-embedding"
+	RuleNotFound            = "not-found"
+	RuleNotInterface        = "not-interface"
+	RuleNotEnum             = "not-enum"
+	RuleUnreachableCase     = "unreachable-case"
+	RulePanickingAssertion  = "panicking-assertion"
+	RuleNoReturn            = "noreturn"
+	RuleVariantList         = "variant-list"
+	RuleStaleAnnotation     = "stale-annotation"
+)
+
+// Rules maps every rule identifier to a short description of the rule.
+var Rules = map[string]string{
+	RuleInexhaustive:        "Case analysis over a sum type must handle every variant",
+	RuleUnsealed:            "A sum type must be a sealed interface",
+	RuleUnsealedByEmbedding: "The seal of a sum type must not be promoted by embedding outside of its package",
+	RuleNotFound:            "A declared sum type must be defined in the same package",
+	RuleNotInterface:        "A type declared with go-sumtype:decl must be an interface",
+	RuleNotEnum:             "A type declared with go-sumtype:enum must have a boolean, numeric or string underlying type",
+	RuleUnreachableCase:     "Case analysis over a sum type must not have unreachable cases",
+	RulePanickingAssertion:  "Single-value type assertions on a sum type panic for other variants",
+	RuleNoReturn:            "A function annotated with go-sumtype:noreturn must never return",
+	RuleVariantList:         "The variants listed in a sum type declaration must be its variants",
+	RuleStaleAnnotation:     "Annotations on case analysis over a sum type must have an effect",
+}
+
+// Finding describes an error reported by Analyzer, for use in machine
+// readable output.
+type Finding struct {
+	// The identifier of the rule that was violated, e.g., "inexhaustive".
+	Rule string
+	// A human readable description of the error.
+	Message string
+	// The range of source text the error refers to. End may be invalid.
+	Pos, End token.Pos
+	// The name of the sum type the error refers to, if any.
+	SumType string
+	// The variants of the sum type the error refers to, if any, sorted by
+	// name. These are the missing variants of inexhaustive case analysis,
+	// and the variants for which a type assertion panics.
+	Variants []types.Object
+}
+
+// NewFinding returns a description of err, which must be an error from the
+// result of Analyzer. If it isn't, then false is returned.
+func NewFinding(err error) (Finding, bool) {
+	d, ok := err.(diagnostic)
+	if !ok {
+		return Finding{}, false
+	}
+	diag := d.diagnostic()
+	f := Finding{
+		Rule:    diag.Category,
+		Message: diag.Message,
+		Pos:     diag.Pos,
+		End:     diag.End,
+	}
+	switch err := err.(type) {
+	case inexhaustiveError:
+		f.SumType = err.Def.Decl.TypeName
+		f.Variants = err.Missing
+	case unsealedError:
+		f.SumType = err.Decl.TypeName
+	case unsealedByEmbeddingError:
+		f.SumType = err.Def.Decl.TypeName
+	case variantListError:
+		f.SumType = err.Def.Decl.TypeName
+	case staleAnnotationError:
+		f.SumType = err.SumType
+	case notFoundError:
+		f.SumType = err.Decl.TypeName
+	case notInterfaceError:
+		f.SumType = err.Decl.TypeName
+	case notEnumError:
+		f.SumType = err.Decl.TypeName
+	case configError:
+		_, f.SumType, _ = configSumType{Type: err.Type}.splitType()
+	case unreachableCaseError:
+		f.SumType = err.Def.Decl.TypeName
+	case panickingAssertError:
+		f.SumType = err.Def.Decl.TypeName
+		f.Variants = err.Panics
+	}
+	f.Variants = append([]types.Object(nil), f.Variants...)
+	sort.Slice(f.Variants, func(i, j int) bool {
+		return f.Variants[i].Name() < f.Variants[j].Name()
+	})
+	return f, true
+}
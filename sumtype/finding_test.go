@@ -0,0 +1,76 @@
+package sumtype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFinding tests that errors are described with their rule, sum type and
+// variants, and with the range of source text they refer to.
+func TestFinding(t *testing.T) {
+	code := `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (*A) sealed() {}
+
+type B struct {}
+func (*B) sealed() {}
+
+type C struct {}
+func (*C) sealed() {}
+
+//go-sumtype:decl U
+
+type U interface { Unsealed() }
+
+func main() {
+	switch T(nil).(type) {
+	case *B:
+	}
+}
+`
+	tmpdir, pkgs := setupPackages(t, code)
+	defer teardownPackage(t, tmpdir)
+
+	errs := runAnalyzer(t, pkgs)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	fset := pkgs[0].Fset
+
+	unsealed, ok := NewFinding(errs[0])
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, RuleUnsealed, unsealed.Rule)
+	assert.Equal(t, "U", unsealed.SumType)
+	assert.Empty(t, unsealed.Variants)
+	assert.Equal(t, 17, fset.Position(unsealed.Pos).Line)
+	assert.Equal(t, 20, fset.Position(unsealed.End).Column)
+
+	inexhaustive, ok := NewFinding(errs[1])
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, RuleInexhaustive, inexhaustive.Rule)
+	assert.Equal(t, errs[1].Error(), inexhaustive.Message)
+	assert.Equal(t, "T", inexhaustive.SumType)
+	var names []string
+	for _, v := range inexhaustive.Variants {
+		names = append(names, v.Name())
+	}
+	assert.Equal(t, []string{"A", "C"}, names)
+	assert.Equal(t, 8, fset.Position(inexhaustive.Variants[0].Pos()).Line)
+	end := fset.Position(inexhaustive.End)
+	assert.Equal(t, 22, end.Line)
+	assert.Equal(t, 23, end.Column)
+
+	_, ok = NewFinding(assert.AnError)
+	assert.False(t, ok)
+}
@@ -0,0 +1,280 @@
+package sumtype
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// fixBody is set by the analyzer's -fix-body flag.
+var fixBody = `panic("TODO")`
+
+func init() {
+	Analyzer.Flags.StringVar(&fixBody, "fix-body", fixBody,
+		"body of case clauses inserted by suggested fixes, as a text/template "+
+			"with fields .SumType and .Variant")
+}
+
+// fixBodyData is the data given to the -fix-body template.
+type fixBodyData struct {
+	// The name of the sum type.
+	SumType string
+	// The missing variant, as written in the inserted case clause.
+	Variant string
+}
+
+// suggestMissingCases returns a suggested fix for the given switch statement,
+// which is over a sum type (with type sumTy) and lacks a case for each of the
+// given variants. The fix inserts a case clause for each missing variant,
+// before the default clause if there is one, and imports any packages
+// needed to refer to the variants.
+//
+// Unexported variants declared in other packages can't be referred to, so
+// no case clauses are inserted for them. If no fix could be suggested, e.g.,
+// because every missing variant is such a variant, then nil is returned.
+func suggestMissingCases(
+	pass *analysis.Pass,
+	def *sumTypeDef,
+	sumTy types.Type,
+	swtch ast.Stmt,
+	body *ast.BlockStmt,
+	missing []types.Object,
+) []analysis.SuggestedFix {
+	file := fileOf(pass, swtch.Pos())
+	if file == nil {
+		return nil
+	}
+	tmpl, err := template.New("fix-body").Parse(fixBody)
+	if err != nil {
+		return nil
+	}
+	imports := newImportQualifier(pass.Pkg, file)
+	indent := indentOf(pass, swtch.Pos())
+	var accessible []types.Object
+	for _, v := range missing {
+		if v.Exported() || v.Pkg().Path() == pass.Pkg.Path() {
+			accessible = append(accessible, v)
+		}
+	}
+	if len(accessible) == 0 {
+		return nil
+	}
+	missing = accessible
+	// Insert cases in the order in which the variants are declared.
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].Pos() < missing[j].Pos()
+	})
+
+	var buf bytes.Buffer
+	for _, v := range missing {
+		variant := variantCaseString(def, sumTy, v, imports.qualify)
+		var caseBody bytes.Buffer
+		data := fixBodyData{SumType: def.Decl.TypeName, Variant: variant}
+		if err := tmpl.Execute(&caseBody, data); err != nil {
+			return nil
+		}
+		fmt.Fprintf(&buf, "%scase %s:\n", indent, variant)
+		for _, line := range strings.Split(caseBody.String(), "\n") {
+			if len(strings.TrimSpace(line)) > 0 {
+				fmt.Fprintf(&buf, "%s\t%s\n", indent, line)
+			}
+		}
+	}
+
+	pos := body.Rbrace
+	for _, stmt := range body.List {
+		if clause := stmt.(*ast.CaseClause); clause.List == nil {
+			pos = clause.Pos()
+			break
+		}
+	}
+	text := buf.Bytes()
+	if lineOf(pass, pos) == lineOf(pass, body.Lbrace) {
+		// The switch is written on a single line, e.g., `switch x {}`.
+		text = append(append([]byte("\n"), text...), indent...)
+	} else {
+		// Edits start at the beginning of a line, so that the inserted
+		// clauses are indented consistently with the existing ones.
+		pos = lineStart(pass, pos)
+	}
+	edits := []analysis.TextEdit{{Pos: pos, End: pos, NewText: text}}
+	edits = append(edits, imports.edits()...)
+	var names []string
+	for _, v := range missing {
+		names = append(names, v.Name())
+	}
+	return []analysis.SuggestedFix{{
+		Message:   "Add cases for " + strings.Join(names, ", "),
+		TextEdits: edits,
+	}}
+}
+
+// variantCaseString returns the given variant of the given sum type as it
+// should be written in a case clause matching a value of type sumTy. Types
+// and constants are qualified with the given qualifier.
+//
+// Type variants are written as pointers when only a pointer to the variant
+// implements the sum type.
+func variantCaseString(
+	def *sumTypeDef,
+	sumTy types.Type,
+	v types.Object,
+	qualify types.Qualifier,
+) string {
+	if _, ok := v.(*types.Const); ok {
+		if q := qualify(v.Pkg()); q != "" {
+			return q + "." + v.Name()
+		}
+		return v.Name()
+	}
+	ty := instantiateVariant(v, typeArgs(sumTy))
+	if iface, ok := sumTy.Underlying().(*types.Interface); ok {
+		if !types.IsInterface(ty) && !types.Implements(ty, iface) {
+			ty = types.NewPointer(ty)
+		}
+	}
+	return types.TypeString(ty, qualify)
+}
+
+// importQualifier qualifies references to packages from within a file,
+// keeping track of the packages that need to be imported to do so.
+type importQualifier struct {
+	pkg  *types.Package
+	file *ast.File
+	// The names by which packages are imported, keyed by path.
+	names map[string]string
+	// The paths of packages that need to be imported, in order.
+	missing []string
+}
+
+func newImportQualifier(pkg *types.Package, file *ast.File) *importQualifier {
+	q := &importQualifier{
+		pkg:   pkg,
+		file:  file,
+		names: make(map[string]string),
+	}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				continue
+			}
+			q.names[path] = spec.Name.Name
+		} else {
+			// We don't know the name of the package from the import
+			// alone. It is filled in by qualify.
+			q.names[path] = ""
+		}
+	}
+	return q
+}
+
+// qualify is a types.Qualifier that refers to packages by the name they are
+// imported as in the file, importing them if necessary.
+func (q *importQualifier) qualify(pkg *types.Package) string {
+	if pkg.Path() == q.pkg.Path() {
+		return ""
+	}
+	name, ok := q.names[pkg.Path()]
+	if !ok {
+		q.missing = append(q.missing, pkg.Path())
+	}
+	if name == "" {
+		name = pkg.Name()
+		q.names[pkg.Path()] = name
+	}
+	return name
+}
+
+// edits returns the text edits that add an import for every package that
+// needs to be imported.
+func (q *importQualifier) edits() []analysis.TextEdit {
+	if len(q.missing) == 0 {
+		return nil
+	}
+	var specs bytes.Buffer
+	for _, path := range q.missing {
+		fmt.Fprintf(&specs, "\t%s\n", strconv.Quote(path))
+	}
+	for _, decl := range q.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			return []analysis.TextEdit{{
+				Pos:     gen.Rparen,
+				End:     gen.Rparen,
+				NewText: specs.Bytes(),
+			}}
+		}
+		// Add another import declaration after a single import.
+		var text bytes.Buffer
+		for _, path := range q.missing {
+			fmt.Fprintf(&text, "\nimport %s", strconv.Quote(path))
+		}
+		return []analysis.TextEdit{{
+			Pos:     gen.End(),
+			End:     gen.End(),
+			NewText: text.Bytes(),
+		}}
+	}
+	text := "\n\nimport (\n" + specs.String() + ")"
+	return []analysis.TextEdit{{
+		Pos:     q.file.Name.End(),
+		End:     q.file.Name.End(),
+		NewText: []byte(text),
+	}}
+}
+
+// fileOf returns the file in the package being analyzed that contains the
+// given position, or nil if there is no such file.
+func fileOf(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, file := range pass.Files {
+		if file.FileStart <= pos && pos <= file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
+
+// lineOf returns the line number of the given position.
+func lineOf(pass *analysis.Pass, pos token.Pos) int {
+	return pass.Fset.File(pos).Line(pos)
+}
+
+// lineStart returns the position of the start of the line containing the
+// given position.
+func lineStart(pass *analysis.Pass, pos token.Pos) token.Pos {
+	tokFile := pass.Fset.File(pos)
+	return tokFile.LineStart(tokFile.Line(pos))
+}
+
+// indentOf returns the whitespace preceding the first token on the line
+// containing the given position.
+func indentOf(pass *analysis.Pass, pos token.Pos) string {
+	tokFile := pass.Fset.File(pos)
+	position := tokFile.Position(pos)
+	src, err := pass.ReadFile(tokFile.Name())
+	if err != nil || tokFile.Size() != len(src) {
+		// Assume the file is formatted with gofmt.
+		return strings.Repeat("\t", position.Column-1)
+	}
+	start := tokFile.Offset(tokFile.LineStart(position.Line))
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
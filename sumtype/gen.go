@@ -0,0 +1,307 @@
+package sumtype
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// GenerateOptions configures the code generated by Generate.
+type GenerateOptions struct {
+	// If non-empty, code is only generated for the sum types with these
+	// names.
+	Types []string
+	// Whether to generate functions that encode and decode sum types as
+	// JSON, identifying variants with a discriminator field.
+	JSON bool
+	// The name of the discriminator field in JSON encodings. If empty,
+	// "type" is used.
+	JSONField string
+}
+
+// Generate returns the source of a Go file, in the same package as pkg,
+// containing a type safe API for every sum type declared in pkg. The package
+// must have been loaded with (at least) syntax and type information.
+//
+// For each sum type T, Generate emits:
+//
+//   - A TVisitor interface with a Visit method for each concrete variant.
+//   - An AcceptT function that calls the visitor method corresponding to the
+//     variant of a T.
+//   - A MatchT function that takes a function for each concrete variant, in
+//     the order in which they are declared, and calls the one corresponding
+//     to the variant of a T.
+//
+// If opts.JSON is set, then Generate also emits MarshalT and UnmarshalT
+// functions that encode and decode a T as JSON. A variant is identified by a
+// discriminator field whose value is the variant's name, or the tag given
+// with a `//go-sumtype:tag ...` line in the variant's doc comment. Variants
+// whose underlying type is a struct are encoded as objects with the
+// discriminator field added, and every other variant is encoded as an object
+// with the discriminator field and a "value" field. A struct variant must not
+// have a JSON field with the same name as the discriminator field, ignoring
+// case.
+//
+// Since the generated code is derived from the set of variants, adding a
+// variant to a sum type breaks compilation of every incomplete visitor and
+// every call to MatchT once the code is regenerated.
+//
+// If any sum type declaration in pkg is invalid, then the corresponding
+// errors are returned, each prefixed with its position.
+func Generate(pkg *packages.Package, opts *GenerateOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	decls := findSumTypeDecls(pkg.Types, pkg.Syntax)
+	defs, errs := findSumTypeDefs(decls)
+	if len(errs) > 0 {
+		return nil, joinDiagnostics(pkg.Fset, errs)
+	}
+	defs, err := selectDefs(defs, opts.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg.Types, imports: make(map[string]string)}
+	var tags map[types.Object]string
+	if opts.JSON {
+		tags = variantTags(pkg)
+	}
+	for i := range defs {
+		if defs[i].Decl.Kind != declInterface {
+			continue
+		}
+		st := g.newGenSumType(&defs[i])
+		g.visitor(st)
+		if opts.JSON {
+			field := opts.JSONField
+			if field == "" {
+				field = "type"
+			}
+			if err := g.json(st, field, tags); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g.finish()
+}
+
+// selectDefs returns the definitions with the given names, in the order of
+// the names. If no names are given, then all definitions are returned.
+func selectDefs(defs []sumTypeDef, names []string) ([]sumTypeDef, error) {
+	if len(names) == 0 {
+		return defs, nil
+	}
+	var selected []sumTypeDef
+	for _, name := range names {
+		found := false
+		for _, def := range defs {
+			if def.Decl.TypeName == name {
+				selected = append(selected, def)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no sum type named '%s' is declared", name)
+		}
+	}
+	return selected, nil
+}
+
+// generator accumulates the generated code for a single package.
+type generator struct {
+	pkg *types.Package
+	buf bytes.Buffer
+	// Imported package names, keyed by path.
+	imports map[string]string
+}
+
+// finish returns the formatted source of the generated file.
+func (g *generator) finish() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by go-sumtype gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+	if len(g.imports) > 0 {
+		var paths []string
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		fmt.Fprintf(&out, "import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&out, "\t%s\n", strconv.Quote(path))
+		}
+		fmt.Fprintf(&out, ")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %s", err)
+	}
+	return src, nil
+}
+
+// importPkg records that the generated code refers to the package with the
+// given path and returns its name.
+func (g *generator) importPkg(path, name string) string {
+	g.imports[path] = name
+	return name
+}
+
+// qualify is a types.Qualifier for the generated code.
+func (g *generator) qualify(pkg *types.Package) string {
+	if pkg.Path() == g.pkg.Path() {
+		return ""
+	}
+	return g.importPkg(pkg.Path(), pkg.Name())
+}
+
+// genSumType describes a sum type as it is referred to by generated code.
+type genSumType struct {
+	def *sumTypeDef
+	// The type parameter list of the sum type, e.g., `[T any]`, or empty.
+	tparams string
+	// The sum type as it is used in generated code, e.g., `Option[T]`.
+	ty string
+	// The concrete variants, in the order in which they are declared.
+	variants []genVariant
+}
+
+// genVariant describes a variant as it is referred to by generated code.
+type genVariant struct {
+	obj types.Object
+	// The name of the variant.
+	name string
+	// The variant as it is written in a case clause, e.g., `*Some[T]`.
+	ty string
+	// Whether the variant is written as a pointer in a case clause.
+	ptr bool
+}
+
+// newGenSumType prepares the given sum type for code generation.
+func (g *generator) newGenSumType(def *sumTypeDef) *genSumType {
+	st := &genSumType{def: def, ty: def.Obj.Name()}
+	sumTy := def.Obj.Type()
+	if named, ok := sumTy.(*types.Named); ok && named.TypeParams().Len() > 0 {
+		tparams := named.TypeParams()
+		var params, names []string
+		for i := 0; i < tparams.Len(); i++ {
+			tp := tparams.At(i)
+			constraint := types.TypeString(tp.Constraint(), g.qualify)
+			params = append(params, tp.Obj().Name()+" "+constraint)
+			names = append(names, tp.Obj().Name())
+		}
+		st.tparams = "[" + strings.Join(params, ", ") + "]"
+		st.ty += "[" + strings.Join(names, ", ") + "]"
+		// Refer to variants as they are used with the sum type
+		// instantiated with its own type parameters.
+		sumTy = selfInstance(sumTy)
+	}
+
+	variants := append([]types.Object(nil), def.Variants...)
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].Pos() < variants[j].Pos()
+	})
+	for _, v := range variants {
+		if types.IsInterface(v.Type()) {
+			// Nested sum types are covered by their concrete variants.
+			continue
+		}
+		ty := variantCaseString(def, sumTy, v, g.qualify)
+		st.variants = append(st.variants, genVariant{
+			obj:  v,
+			name: v.Name(),
+			ty:   ty,
+			ptr:  strings.HasPrefix(ty, "*"),
+		})
+	}
+	return st
+}
+
+// visitor generates the visitor interface and the Accept and Match functions
+// for the given sum type.
+func (g *generator) visitor(st *genSumType) {
+	def := st.def
+	name := def.Obj.Name()
+	exported := def.Obj.Exported()
+	visitor := name + "Visitor"
+	accept := exportedIf(exported, "Accept"+upperFirst(name))
+	match := exportedIf(exported, "Match"+upperFirst(name))
+	tparamNames := ""
+	if st.tparams != "" {
+		tparamNames = st.ty[len(name):]
+	}
+	fmtPkg := g.importPkg("fmt", "fmt")
+
+	fmt.Fprintf(&g.buf, "// %s is implemented by types that handle every variant of %s.\n",
+		visitor, name)
+	fmt.Fprintf(&g.buf, "type %s%s interface {\n", visitor, st.tparams)
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\tVisit%s(v %s)\n", upperFirst(v.name), v.ty)
+	}
+	fmt.Fprintf(&g.buf, "}\n\n")
+
+	fmt.Fprintf(&g.buf, "// %s calls the method of visitor corresponding to the variant of v.\n", accept)
+	fmt.Fprintf(&g.buf, "// It panics if v is nil.\n")
+	fmt.Fprintf(&g.buf, "func %s%s(v %s, visitor %s%s) {\n",
+		accept, st.tparams, st.ty, visitor, tparamNames)
+	fmt.Fprintf(&g.buf, "\tswitch v := v.(type) {\n")
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\tcase %s:\n\t\tvisitor.Visit%s(v)\n", v.ty, upperFirst(v.name))
+	}
+	fmt.Fprintf(&g.buf, "\tdefault:\n")
+	fmt.Fprintf(&g.buf, "\t\tpanic(%s.Sprintf(\"unknown variant of %s: %%T\", v))\n", fmtPkg, name)
+	fmt.Fprintf(&g.buf, "\t}\n}\n\n")
+
+	mtparams := "[R any]"
+	if st.tparams != "" {
+		mtparams = st.tparams[:len(st.tparams)-1] + ", R any]"
+	}
+	fmt.Fprintf(&g.buf, "// %s calls the function corresponding to the variant of v and returns\n", match)
+	fmt.Fprintf(&g.buf, "// its result. There is one function for each variant of %s, in the order\n", name)
+	fmt.Fprintf(&g.buf, "// in which the variants are declared. It panics if v is nil.\n")
+	fmt.Fprintf(&g.buf, "func %s%s(\n\tv %s,\n", match, mtparams, st.ty)
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\t%s func(%s) R,\n", paramName(v.name), v.ty)
+	}
+	fmt.Fprintf(&g.buf, ") R {\n")
+	fmt.Fprintf(&g.buf, "\tswitch v := v.(type) {\n")
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\tcase %s:\n\t\treturn %s(v)\n", v.ty, paramName(v.name))
+	}
+	fmt.Fprintf(&g.buf, "\tdefault:\n")
+	fmt.Fprintf(&g.buf, "\t\tpanic(%s.Sprintf(\"unknown variant of %s: %%T\", v))\n", fmtPkg, name)
+	fmt.Fprintf(&g.buf, "\t}\n}\n\n")
+}
+
+// exportedIf returns name, which must start with an upper case letter, with
+// its first letter in lower case if exported is false.
+func exportedIf(exported bool, name string) string {
+	if exported {
+		return name
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// upperFirst returns name with its first letter in upper case.
+func upperFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// paramName returns the name of the parameter corresponding to the variant
+// with the given name in generated functions. It is prefixed so that it never
+// collides with the name of a type or a Go keyword.
+func paramName(variant string) string {
+	return "on" + upperFirst(variant)
+}
@@ -0,0 +1,277 @@
+package sumtype
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+// TestGenerate tests that the generated visitor API has a method and a
+// function parameter for each concrete variant, in declaration order, and
+// that it compiles and passes the analyzer.
+func TestGenerate(t *testing.T) {
+	files := map[string]string{
+		"main.go": `
+package main
+
+//go-sumtype:decl Expr
+
+type Expr interface { expr() }
+
+type Ident struct { Name string }
+func (*Ident) expr() {}
+
+type Lit int
+func (Lit) expr() {}
+
+type Binary struct { X, Y Expr }
+func (*Binary) expr() {}
+
+//go-sumtype:decl option
+
+type option[T any] interface { isOption(T) }
+
+type some[T any] struct { Value T }
+func (some[T]) isOption(T) {}
+
+type none[T any] struct {}
+func (none[T]) isOption(T) {}
+
+func main() {}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	src, err := Generate(pkgs[0], nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	got := string(src)
+	assert.Contains(t, got, `type ExprVisitor interface {
+	VisitIdent(v *Ident)
+	VisitLit(v Lit)
+	VisitBinary(v *Binary)
+}`)
+	assert.Contains(t, got, "func AcceptExpr(v Expr, visitor ExprVisitor) {")
+	assert.Contains(t, got, `func MatchExpr[R any](
+	v Expr,
+	onIdent func(*Ident) R,
+	onLit func(Lit) R,
+	onBinary func(*Binary) R,
+) R {`)
+	assert.Contains(t, got, `type optionVisitor[T any] interface {
+	VisitSome(v some[T])
+	VisitNone(v none[T])
+}`)
+	assert.Contains(t, got, "func matchOption[T any, R any](")
+
+	path := filepath.Join(tmpdir, "sumtype_gen.go")
+	if err := ioutil.WriteFile(path, src, 0666); err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{Mode: packages.LoadAllSyntax, Dir: tmpdir}
+	pkgs, err = packages.Load(conf, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.FailNow()
+	}
+	assert.Len(t, runAnalyzer(t, pkgs), 0)
+}
+
+// TestGenerateTypes tests that code is only generated for the requested sum
+// types, and that requesting an undeclared sum type is an error.
+func TestGenerateTypes(t *testing.T) {
+	files := map[string]string{
+		"main.go": `
+package main
+
+//go-sumtype:decl T
+
+type T interface { sealed() }
+
+type A struct {}
+func (*A) sealed() {}
+
+//go-sumtype:decl U
+
+type U interface { sealedU() }
+
+type B struct {}
+func (*B) sealedU() {}
+
+func main() {}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	src, err := Generate(pkgs[0], &GenerateOptions{Types: []string{"U"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, string(src), "UVisitor")
+	assert.NotContains(t, string(src), "TVisitor")
+
+	_, err = Generate(pkgs[0], &GenerateOptions{Types: []string{"V"}})
+	assert.EqualError(t, err, "no sum type named 'V' is declared")
+}
+
+// TestGenerateErrors tests that generating code fails if a sum type
+// declaration is invalid, with the position of each invalid declaration.
+func TestGenerateErrors(t *testing.T) {
+	files := map[string]string{
+		"main.go": `
+package main
+
+//go-sumtype:decl T
+
+type T int
+
+//go-sumtype:decl Missing
+
+func main() {}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	_, err := Generate(pkgs[0], nil)
+	path := filepath.Join(tmpdir, "main.go")
+	assert.EqualError(t, err, path+":4:1: type 'T' is not an interface\n"+
+		path+":8:1: type 'Missing' is not defined")
+}
+
+// TestGenerateJSON tests that every variant round-trips through the generated
+// JSON functions, with tags from doc comments and a custom discriminator
+// field, and that unknown tags are rejected.
+func TestGenerateJSON(t *testing.T) {
+	files := map[string]string{
+		"main.go": `
+package main
+
+//go-sumtype:decl Shape
+
+type Shape interface { sealed() }
+
+// Circle is round.
+//
+//go-sumtype:tag circle
+type Circle struct { Radius int }
+func (*Circle) sealed() {}
+
+type Square struct {}
+func (Square) sealed() {}
+
+type Name string
+func (Name) sealed() {}
+`,
+	}
+	// The code using the generated functions is only added once they have
+	// been generated.
+	use := `
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func main() {
+	for _, s := range []Shape{&Circle{Radius: 2}, Square{}, Name("x")} {
+		data, err := MarshalShape(s)
+		if err != nil {
+			panic(err)
+		}
+		got, err := UnmarshalShape(data)
+		if err != nil {
+			panic(err)
+		}
+		if !reflect.DeepEqual(got, s) {
+			panic(fmt.Sprintf("%s: got %#v, want %#v", data, got, s))
+		}
+		fmt.Println(string(data))
+	}
+	_, err := UnmarshalShape([]byte(` + "`" + `{"kind":"Triangle"}` + "`" + `))
+	fmt.Println(err)
+}
+`
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	src, err := Generate(pkgs[0], &GenerateOptions{JSON: true, JSONField: "kind"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	path := filepath.Join(tmpdir, "sumtype_gen.go")
+	if err := ioutil.WriteFile(path, src, 0666); err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(tmpdir, "use.go")
+	if err := ioutil.WriteFile(path, []byte(use), 0666); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = tmpdir
+	out, err := cmd.CombinedOutput()
+	if !assert.NoError(t, err, string(out)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `{"kind":"circle","Radius":2}
+{"kind":"Square"}
+{"kind":"Name","value":"x"}
+unknown variant of Shape: "Triangle"
+`, string(out))
+}
+
+// TestGenerateJSONFieldCollision tests that generating JSON functions fails
+// if a struct variant has a JSON field that would be confused with the
+// discriminator field, including through an embedded struct.
+func TestGenerateJSONFieldCollision(t *testing.T) {
+	files := map[string]string{
+		"main.go": `
+package main
+
+//go-sumtype:decl Shape
+
+type Shape interface { sealed() }
+
+type Var struct { Type string }
+func (*Var) sealed() {}
+
+type Tagged struct { Kind string ` + "`json:\"KIND\"`" + ` }
+func (*Tagged) sealed() {}
+
+type base struct { Variant string }
+
+type Embed struct { base }
+func (*Embed) sealed() {}
+
+type Self struct { *Self; Name string }
+func (*Self) sealed() {}
+
+func main() {}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, ".")
+	defer teardownPackage(t, tmpdir)
+
+	_, err := Generate(pkgs[0], &GenerateOptions{JSON: true})
+	assert.EqualError(t, err, "JSON field 'Type' of variant Var of sum type 'Shape' "+
+		"collides with the discriminator field 'type'")
+	_, err = Generate(pkgs[0], &GenerateOptions{JSON: true, JSONField: "kind"})
+	assert.EqualError(t, err, "JSON field 'KIND' of variant Tagged of sum type 'Shape' "+
+		"collides with the discriminator field 'kind'")
+	_, err = Generate(pkgs[0], &GenerateOptions{JSON: true, JSONField: "variant"})
+	assert.EqualError(t, err, "JSON field 'Variant' of variant Embed of sum type 'Shape' "+
+		"collides with the discriminator field 'variant'")
+	_, err = Generate(pkgs[0], &GenerateOptions{JSON: true, JSONField: "name"})
+	assert.EqualError(t, err, "JSON field 'Name' of variant Self of sum type 'Shape' "+
+		"collides with the discriminator field 'name'")
+}
@@ -0,0 +1,119 @@
+package sumtype
+
+import (
+	"go/types"
+)
+
+// implements returns true if and only if ty or a pointer to ty implements
+// iface, which is the underlying type of a sum type with the given type
+// parameters. (The type parameters are nil when the sum type isn't generic.)
+//
+// If ty is generic, then it is instantiated before checking it, as with
+// instantiateGeneric.
+func implements(ty types.Type, tparams *types.TypeParamList, iface *types.Interface) bool {
+	ty = instantiateGeneric(ty, tparams)
+	if ty == nil {
+		return false
+	}
+	return types.Implements(ty, iface) || types.Implements(types.NewPointer(ty), iface)
+}
+
+// instantiateGeneric returns ty instantiated, if it is generic, for use with
+// a sum type with the given type parameters. Otherwise, ty is returned
+// unchanged. If ty can't be instantiated, then nil is returned.
+//
+// The behavior of types.Implements is unspecified for uninstantiated types.
+// When ty has as many type parameters as the sum type, they are assumed to
+// correspond and ty is instantiated with the sum type's type parameters so
+// that any methods mentioning them line up. Otherwise, ty is instantiated
+// with its own type parameters.
+func instantiateGeneric(ty types.Type, tparams *types.TypeParamList) types.Type {
+	named, ok := ty.(*types.Named)
+	if !ok || named.TypeParams().Len() == 0 {
+		return ty
+	}
+	if tparams.Len() != named.TypeParams().Len() {
+		tparams = named.TypeParams()
+	}
+	inst, err := types.Instantiate(nil, named, typeParamsToTypes(tparams), false)
+	if err != nil {
+		return nil
+	}
+	return inst
+}
+
+// valueType returns the type of the values of a variant of the given type
+// stored in a value of a sum type with the given underlying interface. That
+// is the variant's type if it implements the sum type, or else a pointer to
+// it, since the variant's values are then only pointers. The result is nil
+// if the variant is generic and can't be instantiated.
+//
+// Only the methods of the result can be used to match a variant's values
+// against an interface, e.g., in a type switch. In particular, an interface
+// implemented by *T but not by T doesn't match values of type T.
+func valueType(varty types.Type, sumIface *types.Interface) types.Type {
+	varty = instantiateGeneric(varty, nil)
+	if varty == nil || types.IsInterface(varty) || types.Implements(varty, sumIface) {
+		return varty
+	}
+	return types.NewPointer(varty)
+}
+
+// instantiateVariant returns the type of the given variant as it is used with
+// an instantiation of its sum type with the given type arguments. If the
+// variant isn't generic or doesn't have a type parameter corresponding to
+// each type argument, then its type is returned unchanged.
+func instantiateVariant(v types.Object, targs *types.TypeList) types.Type {
+	named, ok := v.Type().(*types.Named)
+	if !ok || targs.Len() == 0 || named.TypeParams().Len() != targs.Len() {
+		return v.Type()
+	}
+	args := make([]types.Type, targs.Len())
+	for i := range args {
+		args[i] = targs.At(i)
+	}
+	inst, err := types.Instantiate(nil, named, args, false)
+	if err != nil {
+		return v.Type()
+	}
+	return inst
+}
+
+// typeArgs returns the type arguments of the given type, if it is an
+// instantiated named type. Otherwise, it returns nil.
+func typeArgs(ty types.Type) *types.TypeList {
+	if named, ok := types.Unalias(ty).(*types.Named); ok {
+		return named.TypeArgs()
+	}
+	return nil
+}
+
+// selfInstance returns the given type instantiated with its own type
+// parameters, if it is a generic named type. Otherwise, it is returned
+// unchanged.
+func selfInstance(ty types.Type) types.Type {
+	named, ok := ty.(*types.Named)
+	if !ok || named.TypeParams().Len() == 0 {
+		return ty
+	}
+	inst, err := types.Instantiate(nil, named, typeParamsToTypes(named.TypeParams()), false)
+	if err != nil {
+		return ty
+	}
+	return inst
+}
+
+// isGeneric returns true if and only if the given type is a generic named
+// type that has not been instantiated.
+func isGeneric(ty types.Type) bool {
+	named, ok := ty.(*types.Named)
+	return ok && named.TypeParams().Len() > 0 && named.TypeArgs().Len() == 0
+}
+
+func typeParamsToTypes(tparams *types.TypeParamList) []types.Type {
+	tys := make([]types.Type, tparams.Len())
+	for i := range tys {
+		tys[i] = tparams.At(i)
+	}
+	return tys
+}
@@ -0,0 +1,225 @@
+package sumtype
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var reParseTag = regexp.MustCompile(`^//go-sumtype:tag\s+(\S+)\s*$`)
+
+// variantTags returns the discriminator tags given to types in the given
+// package with a `//go-sumtype:tag ...` line in their doc comments.
+func variantTags(pkg *packages.Package) map[types.Object]string {
+	tags := make(map[types.Object]string)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if doc == nil {
+					continue
+				}
+				for _, c := range doc.List {
+					caps := reParseTag.FindStringSubmatch(c.Text)
+					if caps == nil {
+						continue
+					}
+					if obj := pkg.TypesInfo.Defs[spec.Name]; obj != nil {
+						tags[obj] = caps[1]
+					}
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// json generates the JSON encoding and decoding functions for the given sum
+// type, using the given discriminator field and the given variant tags.
+//
+// Variants whose underlying type is a struct are encoded as a JSON object
+// with the discriminator field added to it. Every other variant is encoded as
+// an object with the discriminator field and a "value" field containing the
+// variant's encoding.
+func (g *generator) json(st *genSumType, field string, tags map[types.Object]string) error {
+	name := st.def.Obj.Name()
+	marshal := exportedIf(st.def.Obj.Exported(), "Marshal"+upperFirst(name))
+	unmarshal := exportedIf(st.def.Obj.Exported(), "Unmarshal"+upperFirst(name))
+	fmtPkg := g.importPkg("fmt", "fmt")
+	jsonPkg := g.importPkg("encoding/json", "json")
+
+	variantTag := make(map[string]string)
+	seen := make(map[string]string)
+	for _, v := range st.variants {
+		tag, ok := tags[v.obj]
+		if !ok {
+			tag = v.name
+		}
+		if prev, ok := seen[tag]; ok {
+			return fmt.Errorf(
+				"variants %s and %s of sum type '%s' have the same tag '%s'",
+				prev, v.name, name, tag)
+		}
+		seen[tag] = v.name
+		variantTag[v.name] = tag
+	}
+	if strings.ContainsAny(field, "`,") {
+		return fmt.Errorf("invalid JSON discriminator field '%s'", field)
+	}
+	for _, v := range st.variants {
+		if !isStruct(v.obj) {
+			continue
+		}
+		// encoding/json matches the names of fields case-insensitively
+		// when decoding, so such a field would be confused with the
+		// discriminator field.
+		ty := v.obj.Type().Underlying().(*types.Struct)
+		for _, f := range jsonFieldNames(ty, nil, make(map[*types.Struct]bool)) {
+			if strings.EqualFold(f, field) {
+				return fmt.Errorf(
+					"JSON field '%s' of variant %s of sum type '%s' "+
+						"collides with the discriminator field '%s'",
+					f, v.name, name, field)
+			}
+		}
+	}
+	fieldTag := "`json:" + strconv.Quote(field) + "`"
+	// The start of the encoding of an object with the discriminator field
+	// set to the given tag, e.g., `{"type":"Circle"`.
+	objectStart := func(tag string) string {
+		f, _ := json.Marshal(field)
+		t, _ := json.Marshal(tag)
+		return strconv.Quote("{" + string(f) + ":" + string(t))
+	}
+
+	fmt.Fprintf(&g.buf, "// %s returns the JSON encoding of v, whose %q field identifies its\n",
+		marshal, field)
+	fmt.Fprintf(&g.buf, "// variant. It can be decoded with %s.\n", unmarshal)
+	fmt.Fprintf(&g.buf, "func %s%s(v %s) ([]byte, error) {\n", marshal, st.tparams, st.ty)
+	fmt.Fprintf(&g.buf, "\tvar start string\n\tvar inline bool\n")
+	fmt.Fprintf(&g.buf, "\tswitch v.(type) {\n")
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\tcase %s:\n\t\tstart, inline = %s, %t\n",
+			v.ty, objectStart(variantTag[v.name]), isStruct(v.obj))
+	}
+	fmt.Fprintf(&g.buf, "\tdefault:\n")
+	fmt.Fprintf(&g.buf, "\t\treturn nil, %s.Errorf(\"unknown variant of %s: %%T\", v)\n", fmtPkg, name)
+	fmt.Fprintf(&g.buf, "\t}\n")
+	fmt.Fprintf(&g.buf, "\tdata, err := %s.Marshal(v)\n", jsonPkg)
+	fmt.Fprintf(&g.buf, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&g.buf, "\tout := []byte(start)\n")
+	fmt.Fprintf(&g.buf, "\tif !inline {\n")
+	fmt.Fprintf(&g.buf, "\t\tout = append(out, `,\"value\":`...)\n")
+	fmt.Fprintf(&g.buf, "\t\tout = append(out, data...)\n")
+	fmt.Fprintf(&g.buf, "\t\treturn append(out, '}'), nil\n")
+	fmt.Fprintf(&g.buf, "\t}\n")
+	fmt.Fprintf(&g.buf, "\tif len(data) < 2 || data[0] != '{' {\n")
+	fmt.Fprintf(&g.buf, "\t\treturn nil, %s.Errorf(\"variant %%T of %s is not encoded as a JSON object\", v)\n",
+		fmtPkg, name)
+	fmt.Fprintf(&g.buf, "\t}\n")
+	fmt.Fprintf(&g.buf, "\tif len(data) > 2 {\n\t\tout = append(out, ',')\n\t}\n")
+	fmt.Fprintf(&g.buf, "\treturn append(out, data[1:]...), nil\n")
+	fmt.Fprintf(&g.buf, "}\n\n")
+
+	fmt.Fprintf(&g.buf, "// %s decodes JSON encoded by %s. It fails if the %q\n",
+		unmarshal, marshal, field)
+	fmt.Fprintf(&g.buf, "// field is missing or doesn't identify a variant of %s.\n", name)
+	fmt.Fprintf(&g.buf, "func %s%s(data []byte) (%s, error) {\n", unmarshal, st.tparams, st.ty)
+	fmt.Fprintf(&g.buf, "\tvar head struct {\n")
+	fmt.Fprintf(&g.buf, "\t\tTag *string %s\n", fieldTag)
+	fmt.Fprintf(&g.buf, "\t\tValue %s.RawMessage `json:\"value\"`\n", jsonPkg)
+	fmt.Fprintf(&g.buf, "\t}\n")
+	fmt.Fprintf(&g.buf, "\tif err := %s.Unmarshal(data, &head); err != nil {\n", jsonPkg)
+	fmt.Fprintf(&g.buf, "\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&g.buf, "\tif head.Tag == nil {\n")
+	fmt.Fprintf(&g.buf, "\t\treturn nil, %s.Errorf(%s)\n", fmtPkg,
+		strconv.Quote(fmt.Sprintf("missing %q field in JSON encoding of %s", field, name)))
+	fmt.Fprintf(&g.buf, "\t}\n")
+	fmt.Fprintf(&g.buf, "\tswitch *head.Tag {\n")
+	for _, v := range st.variants {
+		fmt.Fprintf(&g.buf, "\tcase %s:\n", strconv.Quote(variantTag[v.name]))
+		if v.ptr {
+			fmt.Fprintf(&g.buf, "\t\tv := new(%s)\n", v.ty[1:])
+		} else {
+			fmt.Fprintf(&g.buf, "\t\tv := new(%s)\n", v.ty)
+		}
+		src := "head.Value"
+		if isStruct(v.obj) {
+			src = "data"
+		}
+		fmt.Fprintf(&g.buf, "\t\tif err := %s.Unmarshal(%s, v); err != nil {\n", jsonPkg, src)
+		fmt.Fprintf(&g.buf, "\t\t\treturn nil, err\n\t\t}\n")
+		if v.ptr {
+			fmt.Fprintf(&g.buf, "\t\treturn v, nil\n")
+		} else {
+			fmt.Fprintf(&g.buf, "\t\treturn *v, nil\n")
+		}
+	}
+	fmt.Fprintf(&g.buf, "\tdefault:\n")
+	fmt.Fprintf(&g.buf, "\t\treturn nil, %s.Errorf(\"unknown variant of %s: %%q\", *head.Tag)\n",
+		fmtPkg, name)
+	fmt.Fprintf(&g.buf, "\t}\n}\n\n")
+	return nil
+}
+
+// jsonFieldNames appends the names of the fields of the given struct type in
+// its JSON encoding to names, and returns the result. As with encoding/json,
+// the fields of embedded structs without a name in their tag are promoted.
+//
+// The given set of struct types records the structs already visited, so that
+// structs embedding themselves (through a pointer) are only visited once.
+func jsonFieldNames(st *types.Struct, names []string, visited map[*types.Struct]bool) []string {
+	if visited[st] {
+		return names
+	}
+	visited[st] = true
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Embedded() && name == "" {
+			ty := f.Type()
+			if ptr, ok := ty.(*types.Pointer); ok {
+				ty = ptr.Elem()
+			}
+			if embedded, ok := ty.Underlying().(*types.Struct); ok {
+				names = jsonFieldNames(embedded, names, visited)
+				continue
+			}
+		}
+		if !f.Exported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name()
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// isStruct returns true if and only if the underlying type of the given
+// variant is a struct.
+func isStruct(v types.Object) bool {
+	_, ok := v.Type().Underlying().(*types.Struct)
+	return ok
+}
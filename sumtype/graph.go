@@ -0,0 +1,310 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// GraphOptions configures the graph returned by NewGraph.
+type GraphOptions struct {
+	// If non-empty, only sum types declared in packages with these import
+	// paths are expanded into their variants. Other sum types may still
+	// appear in the graph, e.g., when a field refers to them.
+	Packages []string
+	// If non-empty, the graph only contains the sum types with these names,
+	// and those reachable from them. A name is either the name of a sum
+	// type, e.g., `Expr`, or its fully qualified name, e.g.,
+	// `example.com/x/ast.Expr`.
+	Roots []string
+	// Whether to include an edge from each struct variant to each sum type
+	// that one of its fields refers to.
+	Fields bool
+}
+
+// Graph is a directed graph of sum types and their variants.
+type Graph struct {
+	// The nodes of the graph, starting with the sum types that the graph
+	// was built from.
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a sum type or a variant in a Graph.
+type GraphNode struct {
+	// The fully qualified name of the type or constant, e.g.,
+	// `example.com/x/ast.Expr`, which uniquely identifies the node.
+	ID string
+	// The type or constant as it is written in a case clause, qualified by
+	// its package name, e.g., `*ast.Ident`.
+	Label string
+	// Whether the node is a sum type. A nested sum type, i.e., one that is
+	// a variant of another sum type, is a sum type too.
+	SumType bool
+	// Whether the node is an enum.
+	Enum bool
+}
+
+// GraphEdge is an edge in a Graph.
+type GraphEdge struct {
+	From, To string
+	// The name of the field of From that refers to the sum type To, or
+	// empty if To is a variant of From.
+	Field string
+}
+
+// NewGraph returns the graph of the sum types declared in the given packages,
+// or declared in the configuration file and defined in them, which must have
+// been loaded with (at least) syntax and type information.
+//
+// Each sum type has an edge to each of its variants. When a variant is an
+// interface (e.g., a nested sum type), the variants implementing it are
+// drawn below it rather than below the sum type, so that nested sum types
+// appear as sub-hierarchies.
+//
+// If any sum type declaration is invalid, or if a root named by opts doesn't
+// name a sum type, then an error is returned.
+func NewGraph(pkgs []*packages.Package, opts *GraphOptions) (*Graph, error) {
+	if opts == nil {
+		opts = &GraphOptions{}
+	}
+	defs, err := findPackagesDefs(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		pi, pj := defs[i].Decl.Package.Path(), defs[j].Decl.Package.Path()
+		if pi != pj {
+			return pi < pj
+		}
+		return defs[i].Decl.TypeName < defs[j].Decl.TypeName
+	})
+
+	b := &graphBuilder{
+		defs:     defs,
+		idx:      newDefIndex(defs),
+		opts:     opts,
+		nodes:    make(map[string]bool),
+		edges:    make(map[GraphEdge]bool),
+		expanded: make(map[*sumTypeDef]bool),
+	}
+	var queue []*sumTypeDef
+	if len(opts.Roots) == 0 {
+		for i := range defs {
+			if b.inPackages(&defs[i]) {
+				queue = append(queue, &defs[i])
+			}
+		}
+	}
+	for _, root := range opts.Roots {
+		def := b.findRoot(root)
+		if def == nil {
+			return nil, fmt.Errorf("no sum type named '%s' is declared", root)
+		}
+		queue = append(queue, def)
+	}
+	for _, def := range queue {
+		b.addNode(def.Obj, b.label(def, def.Obj))
+	}
+	for len(queue) > 0 {
+		def := queue[0]
+		queue = queue[1:]
+		queue = append(queue, b.expand(def)...)
+	}
+	return &b.graph, nil
+}
+
+// graphBuilder accumulates the nodes and edges of a Graph.
+type graphBuilder struct {
+	defs  []sumTypeDef
+	idx   *defIndex
+	opts  *GraphOptions
+	graph Graph
+	// The IDs of the nodes in the graph.
+	nodes map[string]bool
+	edges map[GraphEdge]bool
+	// The sum types whose variants have been added to the graph.
+	expanded map[*sumTypeDef]bool
+}
+
+// expand adds the variants of the given sum type to the graph, along with
+// the sum types that its struct variants refer to if opts.Fields is set. It
+// returns the sum types that should be expanded in turn.
+func (b *graphBuilder) expand(def *sumTypeDef) []*sumTypeDef {
+	if b.expanded[def] || !b.inPackages(def) {
+		return nil
+	}
+	b.expanded[def] = true
+
+	var next []*sumTypeDef
+	parents := def.hierarchy()
+	variants := append([]types.Object(nil), def.Variants...)
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].Pos() < variants[j].Pos()
+	})
+	for _, v := range variants {
+		var from types.Object = def.Obj
+		if parent := parents[v]; parent != nil {
+			from = parent
+		}
+		b.addNode(v, b.label(def, v))
+		b.addEdge(GraphEdge{From: objectID(from), To: objectID(v)})
+		if nested := b.findDef(v); nested != nil {
+			next = append(next, nested)
+		}
+		if !b.opts.Fields {
+			continue
+		}
+		st, ok := v.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			for _, ref := range b.referencedDefs(field.Type(), nil) {
+				b.addNode(ref.Obj, b.label(ref, ref.Obj))
+				b.addEdge(GraphEdge{
+					From:  objectID(v),
+					To:    objectID(ref.Obj),
+					Field: field.Name(),
+				})
+				next = append(next, ref)
+			}
+		}
+	}
+	return next
+}
+
+// hierarchy returns the innermost interface variant of this sum type that
+// each of its other variants implements, if any.
+func (def *sumTypeDef) hierarchy() map[types.Object]types.Object {
+	var ifaces []types.Object
+	for _, v := range def.Variants {
+		if types.IsInterface(v.Type()) {
+			ifaces = append(ifaces, v)
+		}
+	}
+	// within returns true if and only if x implements the interface
+	// variant y, and y doesn't implement x.
+	within := func(x types.Object, y types.Object) bool {
+		if x == y {
+			return false
+		}
+		yiface := y.Type().Underlying().(*types.Interface)
+		if !implements(x.Type(), nil, yiface) {
+			return false
+		}
+		xiface, ok := x.Type().Underlying().(*types.Interface)
+		return !ok || !types.Implements(y.Type(), xiface)
+	}
+	parents := make(map[types.Object]types.Object)
+	for _, v := range def.Variants {
+		for _, iface := range ifaces {
+			if !within(v, iface) {
+				continue
+			}
+			if parent := parents[v]; parent == nil || within(iface, parent) {
+				parents[v] = iface
+			}
+		}
+	}
+	return parents
+}
+
+// referencedDefs appends the sum types that the given type refers to
+// directly, or through pointers, slices, arrays, maps and channels, to defs
+// and returns the result.
+func (b *graphBuilder) referencedDefs(ty types.Type, defs []*sumTypeDef) []*sumTypeDef {
+	switch ty := types.Unalias(ty).(type) {
+	case *types.Named:
+		if def := b.findDef(ty.Obj()); def != nil {
+			defs = append(defs, def)
+		}
+	case *types.Pointer:
+		defs = b.referencedDefs(ty.Elem(), defs)
+	case *types.Slice:
+		defs = b.referencedDefs(ty.Elem(), defs)
+	case *types.Array:
+		defs = b.referencedDefs(ty.Elem(), defs)
+	case *types.Map:
+		defs = b.referencedDefs(ty.Key(), defs)
+		defs = b.referencedDefs(ty.Elem(), defs)
+	case *types.Chan:
+		defs = b.referencedDefs(ty.Elem(), defs)
+	}
+	return defs
+}
+
+// findDef returns the sum type definition of the given type name, or nil if
+// it isn't a sum type.
+func (b *graphBuilder) findDef(obj types.Object) *sumTypeDef {
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return b.idx.findName(tn)
+}
+
+// findRoot returns the sum type definition with the given name or fully
+// qualified name, or nil if there is no such sum type.
+func (b *graphBuilder) findRoot(name string) *sumTypeDef {
+	for i := range b.defs {
+		def := &b.defs[i]
+		if name == def.Decl.TypeName || name == objectID(def.Obj) {
+			return def
+		}
+	}
+	return nil
+}
+
+// inPackages returns true if and only if the given sum type is declared in
+// one of the packages in opts.Packages, or if there are no such packages.
+func (b *graphBuilder) inPackages(def *sumTypeDef) bool {
+	if len(b.opts.Packages) == 0 {
+		return true
+	}
+	for _, path := range b.opts.Packages {
+		if path == def.Decl.Package.Path() {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the label of the node for the given variant of the given sum
+// type, or for the sum type itself.
+func (b *graphBuilder) label(def *sumTypeDef, v types.Object) string {
+	qualify := func(pkg *types.Package) string { return pkg.Name() }
+	if v == types.Object(def.Obj) {
+		return qualify(v.Pkg()) + "." + v.Name()
+	}
+	return variantCaseString(def, selfInstance(def.Obj.Type()), v, qualify)
+}
+
+func (b *graphBuilder) addNode(obj types.Object, label string) {
+	node := GraphNode{ID: objectID(obj), Label: label}
+	if def := b.findDef(obj); def != nil {
+		node.SumType = true
+		node.Enum = def.Decl.Kind == declEnum
+	}
+	if b.nodes[node.ID] {
+		return
+	}
+	b.nodes[node.ID] = true
+	b.graph.Nodes = append(b.graph.Nodes, node)
+}
+
+func (b *graphBuilder) addEdge(edge GraphEdge) {
+	if b.edges[edge] {
+		return
+	}
+	b.edges[edge] = true
+	b.graph.Edges = append(b.graph.Edges, edge)
+}
+
+// objectID returns the fully qualified name of the given object.
+func objectID(obj types.Object) string {
+	return obj.Pkg().Path() + "." + obj.Name()
+}
@@ -0,0 +1,95 @@
+package sumtype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const graphTestAST = `
+package ast
+
+//go-sumtype:decl Node
+
+type Node interface { node() }
+
+//go-sumtype:decl Expr
+
+type Expr interface { Node; expr() }
+
+//go-sumtype:decl Stmt
+
+type Stmt interface { Node; stmt() }
+
+type Ident struct { Name string }
+func (*Ident) node() {}
+func (*Ident) expr() {}
+
+type Block struct { List []Stmt }
+func (Block) node() {}
+func (Block) stmt() {}
+
+type ExprStmt struct { X Expr }
+func (ExprStmt) node() {}
+func (ExprStmt) stmt() {}
+`
+
+// TestGraph tests that the variants of nested sum types are drawn below them
+// rather than below the sum types containing them.
+func TestGraph(t *testing.T) {
+	files := map[string]string{"ast/ast.go": graphTestAST}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+
+	g, err := NewGraph(pkgs, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	const p = "example.com/m/ast."
+	assert.Equal(t, []GraphNode{
+		{ID: p + "Expr", Label: "ast.Expr", SumType: true},
+		{ID: p + "Node", Label: "ast.Node", SumType: true},
+		{ID: p + "Stmt", Label: "ast.Stmt", SumType: true},
+		{ID: p + "Ident", Label: "*ast.Ident"},
+		{ID: p + "Block", Label: "ast.Block"},
+		{ID: p + "ExprStmt", Label: "ast.ExprStmt"},
+	}, g.Nodes)
+	assert.ElementsMatch(t, []GraphEdge{
+		{From: p + "Expr", To: p + "Ident"},
+		{From: p + "Node", To: p + "Expr"},
+		{From: p + "Node", To: p + "Stmt"},
+		{From: p + "Stmt", To: p + "Block"},
+		{From: p + "Stmt", To: p + "ExprStmt"},
+	}, g.Edges)
+}
+
+// TestGraphRootsFields tests that a graph starting from a root only includes
+// the sum types reachable from it, including through fields.
+func TestGraphRootsFields(t *testing.T) {
+	files := map[string]string{"ast/ast.go": graphTestAST}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+
+	g, err := NewGraph(pkgs, &GraphOptions{Roots: []string{"Stmt"}, Fields: true})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	const p = "example.com/m/ast."
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []string{
+		p + "Stmt", p + "Block", p + "ExprStmt", p + "Expr", p + "Ident",
+	}, ids)
+	assert.ElementsMatch(t, []GraphEdge{
+		{From: p + "Stmt", To: p + "Block"},
+		{From: p + "Block", To: p + "Stmt", Field: "List"},
+		{From: p + "Stmt", To: p + "ExprStmt"},
+		{From: p + "ExprStmt", To: p + "Expr", Field: "X"},
+		{From: p + "Expr", To: p + "Ident"},
+	}, g.Edges)
+
+	_, err = NewGraph(pkgs, &GraphOptions{Roots: []string{"Nope"}})
+	assert.EqualError(t, err, "no sum type named 'Nope' is declared")
+}
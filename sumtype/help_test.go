@@ -0,0 +1,154 @@
+package sumtype
+
+import (
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+func setupPackages(t testing.TB, code string) (string, []*packages.Package) {
+	tmpdir, err := ioutil.TempDir("", "go-test-sumtype-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := filepath.Join(tmpdir, "src.go")
+	if err := ioutil.WriteFile(srcPath, []byte(code), 0666); err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{Mode: packages.LoadAllSyntax, Tests: true}
+	pkgs, err := packages.Load(conf, srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpdir, pkgs
+}
+
+// setupModule writes the given files, keyed by slash-separated path, to a new
+// module named "example.com/m" and loads the packages matching the given
+// patterns from it. If the files include a go.mod file, then it's used
+// instead, e.g., to require other modules.
+func setupModule(
+	t testing.TB,
+	files map[string]string,
+	patterns ...string,
+) (string, []*packages.Package) {
+	tmpdir, err := ioutil.TempDir("", "go-test-sumtype-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["go.mod"]; !ok {
+		files["go.mod"] = "module example.com/m\n\ngo 1.22\n"
+	}
+	for name, code := range files {
+		path := filepath.Join(tmpdir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(code), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   tmpdir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(conf, patterns...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.FailNow()
+	}
+	return tmpdir, pkgs
+}
+
+// chdir changes the working directory to the given directory, and returns a
+// function that changes it back.
+func chdir(t testing.TB, dir string) func() {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func teardownPackage(t testing.TB, dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runAnalyzer runs the sum type analyzer on the given packages and returns
+// every error it found in them.
+//
+// Errors in files shared by a package and its test variant are reported only
+// once.
+func runAnalyzer(t testing.TB, pkgs []*packages.Package) []error {
+	graph, err := checker.Analyze([]*analysis.Analyzer{Analyzer}, pkgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type key struct {
+		pos token.Position
+		msg string
+	}
+	seen := make(map[key]bool)
+	var errs []error
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			t.Fatal(act.Err)
+		}
+		for _, err := range act.Result.([]error) {
+			pos := err.(diagnostic).diagnostic().Pos
+			k := key{act.Package.Fset.Position(pos), err.Error()}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// applyFix applies the edits of the given suggested fix to the file at the
+// given path and returns the result formatted with gofmt.
+func applyFix(
+	t *testing.T,
+	fset *token.FileSet,
+	path string,
+	fix analysis.SuggestedFix,
+) string {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := append([]analysis.TextEdit(nil), fix.TextEdits...)
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Pos > edits[j].Pos })
+	for _, edit := range edits {
+		start := fset.Position(edit.Pos).Offset
+		end := fset.Position(edit.End).Offset
+		src = append(src[:start], append(edit.NewText, src[end:]...)...)
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+	return string(formatted)
+}
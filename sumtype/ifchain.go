@@ -0,0 +1,119 @@
+package sumtype
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ifChainLink is a single `if v, ok := x.(T); ok { ... }` in a chain of
+// if/else-if statements.
+type ifChainLink struct {
+	// The expression being type asserted, i.e., x.
+	X ast.Expr
+	// The type asserted, i.e., T.
+	Type ast.Expr
+}
+
+// checkIfChain performs an exhaustiveness check on the chain of if/else-if
+// statements starting with the given if statement, if every condition in the
+// chain is a comma-ok type assertion on the same value of a sum type. If the
+// chain does not cover all variants of that sum type, then an error is
+// returned indicating which variants were missed. An error is also returned
+// for every type assertion in the chain that can never succeed.
+//
+// Every if statement that is part of the chain (other than the first) is
+// added to the given set, so that callers can avoid checking it again.
+//
+// A chain with a final else clause is treated like a type switch with a
+// default case: unless the else clause never completes normally,
+// exhaustiveness checks are disabled. A chain consisting of a single if
+// statement without an else clause isn't checked at all, since it is a test
+// for one variant rather than case analysis.
+//
+// Variants acknowledged by the given annotation, if any, aren't missing.
+func checkIfChain(
+	pass *analysis.Pass,
+	idx *defIndex,
+	stmt *ast.IfStmt,
+	inChain map[*ast.IfStmt]bool,
+	ann *annotation,
+) []error {
+	var links []ifChainLink
+	var final *ast.BlockStmt
+	for cur := stmt; ; {
+		link, ok := commaOkLink(pass, cur)
+		if !ok {
+			return nil
+		}
+		if len(links) > 0 {
+			if types.ExprString(link.X) != types.ExprString(links[0].X) {
+				return nil
+			}
+			inChain[cur] = true
+		}
+		links = append(links, link)
+		if next, ok := cur.Else.(*ast.IfStmt); ok {
+			cur = next
+			continue
+		}
+		final, _ = cur.Else.(*ast.BlockStmt)
+		break
+	}
+	if final == nil && len(links) < 2 {
+		return nil
+	}
+	ty := pass.TypesInfo.TypeOf(links[0].X)
+	def := idx.find(ty)
+	if def == nil {
+		return nil
+	}
+	ann.acknowledge(def, nil)
+	var caseExprs []ast.Expr
+	var variantTypes []types.Type
+	for _, link := range links {
+		caseExprs = append(caseExprs, link.Type)
+		variantTypes = append(variantTypes, pass.TypesInfo.TypeOf(link.Type))
+	}
+	errs := checkTypeCases(pass, def, ty, caseExprs)
+	if final != nil && !neverCompletes(pass, final.List) {
+		return errs
+	}
+	if missing := ann.acknowledge(def, def.missing(ty, variantTypes)); len(missing) > 0 {
+		errs = append([]error{inexhaustiveError{
+			Pos:     stmt.Pos(),
+			End:     stmt.Body.Lbrace,
+			Def:     *def,
+			Missing: missing,
+		}}, errs...)
+	}
+	return errs
+}
+
+// commaOkLink returns the type assertion tested by the given if statement,
+// if it has the form `if v, ok := x.(T); ok { ... }`.
+func commaOkLink(pass *analysis.Pass, stmt *ast.IfStmt) (ifChainLink, bool) {
+	assign, ok := stmt.Init.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 2 || len(assign.Rhs) != 1 {
+		return ifChainLink{}, false
+	}
+	expr, ok := astutil.Unparen(assign.Rhs[0]).(*ast.TypeAssertExpr)
+	if !ok || expr.Type == nil {
+		return ifChainLink{}, false
+	}
+	okIdent, ok := assign.Lhs[1].(*ast.Ident)
+	if !ok {
+		return ifChainLink{}, false
+	}
+	cond, ok := astutil.Unparen(stmt.Cond).(*ast.Ident)
+	if !ok {
+		return ifChainLink{}, false
+	}
+	okObj := pass.TypesInfo.ObjectOf(okIdent)
+	if okObj == nil || okObj != pass.TypesInfo.ObjectOf(cond) {
+		return ifChainLink{}, false
+	}
+	return ifChainLink{X: expr.X, Type: expr.Type}, true
+}
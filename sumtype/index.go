@@ -0,0 +1,251 @@
+package sumtype
+
+import (
+	"go/types"
+	"sync"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// defIndex indexes sum type definitions, so that the definition of the sum
+// type of a value can be found in constant (expected) time, no matter how
+// many sum types there are.
+//
+// An index is safe for concurrent use once it has been built.
+type defIndex struct {
+	// Definitions of interfaces and enums, keyed by the qualified name of
+	// the sum type. (See qualifiedName.)
+	ifaces, enums map[string]*sumTypeDef
+	// Definitions of interfaces, keyed by their underlying interface type.
+	// This finds sum types used through unnamed (but identical) interface
+	// types.
+	byIface typeutil.Map
+}
+
+// newDefIndex returns an index of the given definitions. When several
+// definitions are for the same type, the first one is used.
+func newDefIndex(defs []sumTypeDef) *defIndex {
+	idx := &defIndex{
+		ifaces: make(map[string]*sumTypeDef),
+		enums:  make(map[string]*sumTypeDef),
+	}
+	for i := range defs {
+		def := &defs[i]
+		byName := idx.ifaces
+		if def.Decl.Kind == declEnum {
+			byName = idx.enums
+		}
+		name := qualifiedName(def.Obj)
+		if _, ok := byName[name]; !ok {
+			byName[name] = def
+		}
+		if def.Ty != nil && idx.byIface.At(def.Ty) == nil {
+			idx.byIface.Set(def.Ty, def)
+		}
+	}
+	return idx
+}
+
+// find returns the definition of the sum type (that is an interface)
+// corresponding to the given type. If no such sum type definition exists,
+// then nil is returned.
+func (idx *defIndex) find(needle types.Type) *sumTypeDef {
+	if needle == nil {
+		return nil
+	}
+	if named, ok := types.Unalias(needle).(*types.Named); ok && !isLocal(named.Obj()) {
+		if def := idx.ifaces[qualifiedName(named.Obj())]; def != nil {
+			return def
+		}
+	}
+	if iface, ok := needle.Underlying().(*types.Interface); ok {
+		if def, ok := idx.byIface.At(iface).(*sumTypeDef); ok {
+			return def
+		}
+	}
+	return nil
+}
+
+// findEnum returns the definition of the enum corresponding to the given
+// type. If no such enum definition exists, then nil is returned.
+func (idx *defIndex) findEnum(needle types.Type) *sumTypeDef {
+	if needle == nil {
+		return nil
+	}
+	named, ok := types.Unalias(needle).(*types.Named)
+	if !ok || isLocal(named.Obj()) {
+		return nil
+	}
+	return idx.enums[qualifiedName(named.Obj())]
+}
+
+// findName returns the definition of the sum type, of either kind, with the
+// given type name. If there is no such sum type, then nil is returned.
+func (idx *defIndex) findName(tn *types.TypeName) *sumTypeDef {
+	if isLocal(tn) {
+		return nil
+	}
+	name := qualifiedName(tn)
+	if def := idx.ifaces[name]; def != nil {
+		return def
+	}
+	return idx.enums[name]
+}
+
+// qualifiedName returns the name of the given type name qualified by the
+// path of the package declaring it. Type names with the same qualified name
+// are the same as far as sameTypeName is concerned.
+//
+// Local types have no qualified name, since they're only the same as
+// themselves, so the empty string is returned for them.
+func qualifiedName(tn *types.TypeName) string {
+	if isLocal(tn) {
+		return ""
+	}
+	if tn.Pkg() == nil {
+		return tn.Name()
+	}
+	return tn.Pkg().Path() + "." + tn.Name()
+}
+
+// typeIndex maps types to the integers (e.g., indexes into a list) added
+// with them, where types are looked up with sameType rather than identity.
+// Lookups take constant (expected) time.
+type typeIndex struct {
+	// Named types, which sameType matches by name, keyed by qualified name.
+	named map[string][]typeIndexEntry
+	// Every other type, keyed by the type itself.
+	other typeutil.Map
+}
+
+type typeIndexEntry struct {
+	ty types.Type
+	i  int
+}
+
+func newTypeIndex() *typeIndex {
+	return &typeIndex{named: make(map[string][]typeIndexEntry)}
+}
+
+// add adds the given type to the index, along with the given integer.
+func (ti *typeIndex) add(ty types.Type, i int) {
+	ty = types.Unalias(ty)
+	if named, ok := ty.(*types.Named); ok {
+		name := qualifiedName(named.Obj())
+		ti.named[name] = append(ti.named[name], typeIndexEntry{ty, i})
+		return
+	}
+	is, _ := ti.other.At(ty).([]int)
+	ti.other.Set(ty, append(is, i))
+}
+
+// lookup returns the integers added with the types that are the same as the
+// given type, in the order in which they were added.
+func (ti *typeIndex) lookup(ty types.Type) []int {
+	ty = types.Unalias(ty)
+	named, ok := ty.(*types.Named)
+	if !ok {
+		is, _ := ti.other.At(ty).([]int)
+		return is
+	}
+	var is []int
+	for _, e := range ti.named[qualifiedName(named.Obj())] {
+		if sameType(e.ty, ty) {
+			is = append(is, e.i)
+		}
+	}
+	return is
+}
+
+// variantIndex is precomputed information about the variants of a sum type,
+// instantiated with particular type arguments. It is used to check case
+// analysis over the sum type in time linear in the number of variants and
+// cases.
+//
+// A variantIndex is immutable, and therefore safe for concurrent use.
+type variantIndex struct {
+	// The types of the variants, in the order of sumTypeDef.Variants.
+	types []types.Type
+	// The indexes of the variants, keyed by their types (through any
+	// pointers).
+	byType *typeIndex
+	// containers[i] lists the indexes of the other variants that are
+	// interfaces (i.e., nested sum types) implemented by the variant at
+	// index i.
+	containers [][]int
+	// members[i] lists the indexes of the concrete variants implementing the
+	// variant at index i if it is an interface, and is nil otherwise.
+	members [][]int
+}
+
+// newVariantIndex returns an index of the variants of the given sum type,
+// instantiated with the given type arguments.
+//
+// This takes time proportional to the number of variants multiplied by the
+// number of variants that are interfaces, which is typically small.
+func newVariantIndex(def *sumTypeDef, targs *types.TypeList) *variantIndex {
+	n := len(def.Variants)
+	vi := &variantIndex{
+		types:      make([]types.Type, n),
+		byType:     newTypeIndex(),
+		containers: make([][]int, n),
+		members:    make([][]int, n),
+	}
+	var ifaces []int
+	for i, v := range def.Variants {
+		vi.types[i] = instantiateVariant(v, targs)
+		vi.byType.add(indirect(vi.types[i]), i)
+		if types.IsInterface(vi.types[i]) {
+			ifaces = append(ifaces, i)
+			vi.members[i] = []int{}
+		}
+	}
+	for _, j := range ifaces {
+		iface := vi.types[j].Underlying().(*types.Interface)
+		for i, varty := range vi.types {
+			if i == j || !implements(varty, nil, iface) {
+				continue
+			}
+			vi.containers[i] = append(vi.containers[i], j)
+			if !types.IsInterface(varty) {
+				vi.members[j] = append(vi.members[j], i)
+			}
+		}
+	}
+	return vi
+}
+
+// variantIndexOnce is an index of the variants of a sum type that is built
+// at most once, when it's first needed. Sum types imported from other
+// packages are often never used in case analysis, so building their indexes
+// up front would be wasted effort.
+type variantIndexOnce struct {
+	once sync.Once
+	vi   *variantIndex
+}
+
+// variants returns the index of the variants of this sum type, instantiated
+// for use with a value of type sumTy.
+//
+// The index is only built once for sum types that aren't generic.
+// Instantiations of generic sum types are indexed on every call.
+func (def *sumTypeDef) variants(sumTy types.Type) *variantIndex {
+	targs := typeArgs(sumTy)
+	if targs.Len() > 0 || def.index == nil {
+		return newVariantIndex(def, targs)
+	}
+	def.index.once.Do(func() {
+		def.index.vi = newVariantIndex(def, nil)
+	})
+	return def.index.vi
+}
+
+// contains returns true if and only if the given list of indexes contains i.
+func contains(is []int, i int) bool {
+	for _, j := range is {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package sumtype
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SumType describes a sum type declared in a package, as returned by List.
+type SumType struct {
+	// The import path of the package declaring the sum type.
+	Package string
+	Name    string
+	// Whether the sum type is an enum rather than a sealed interface.
+	Enum bool
+	// The position of the sum type's declaration comment, or of the package
+	// clause of the package's first file if the sum type is declared in the
+	// configuration file.
+	Decl token.Position
+	// The position of the sum type's definition.
+	Pos      token.Position
+	Variants []Variant
+	// The number of switch statements over the sum type in the packages
+	// given to List: type switches for sealed interfaces and expression
+	// switches for enums.
+	Switches int
+}
+
+// Variant describes a variant of a sum type.
+type Variant struct {
+	Name string
+	// The variant as it is written in a case clause, e.g., `*Ident`.
+	Type string
+	// How the variant implements its sum type: "value" if the variant's
+	// methods all have value receivers and "pointer" if only a pointer to
+	// the variant implements the sum type. It is empty for variants that
+	// are interfaces (i.e., nested sum types) and for enum constants.
+	Receiver string
+	Pos      token.Position
+}
+
+// List returns every sum type declared in the given packages, or declared
+// in the configuration file (given by the analyzer's -config flag, or else
+// discovered in the main module) and defined in them, along with its
+// variants, in the order in which they are declared, and the number of
+// switch statements over it in the given packages. The packages must have
+// been loaded with (at least) syntax and type information, and each package
+// must only be given once (e.g., not along with its test variant).
+//
+// Sum types are sorted by package and then by name. If any sum type
+// declaration is invalid, then the corresponding errors are returned, each
+// prefixed with its position.
+func List(pkgs []*packages.Package) ([]SumType, error) {
+	defs, err := findPackagesDefs(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	switches := countSwitches(pkgs, defs)
+
+	var fset *token.FileSet
+	if len(pkgs) > 0 {
+		fset = pkgs[0].Fset
+	}
+	var sts []SumType
+	for i := range defs {
+		def := &defs[i]
+		st := SumType{
+			Package:  def.Decl.Package.Path(),
+			Name:     def.Decl.TypeName,
+			Enum:     def.Decl.Kind == declEnum,
+			Decl:     fset.Position(def.Decl.Pos),
+			Pos:      fset.Position(def.Obj.Pos()),
+			Switches: switches[def.Obj],
+		}
+		sumTy := selfInstance(def.Obj.Type())
+		qualify := types.RelativeTo(def.Obj.Pkg())
+		variants := append([]types.Object(nil), def.Variants...)
+		sort.SliceStable(variants, func(i, j int) bool {
+			return variants[i].Pos() < variants[j].Pos()
+		})
+		for _, v := range variants {
+			ty := variantCaseString(def, sumTy, v, qualify)
+			variant := Variant{Name: v.Name(), Type: ty, Pos: fset.Position(v.Pos())}
+			if _, ok := v.(*types.TypeName); ok && !types.IsInterface(v.Type()) {
+				if strings.HasPrefix(ty, "*") {
+					variant.Receiver = "pointer"
+				} else {
+					variant.Receiver = "value"
+				}
+			}
+			st.Variants = append(st.Variants, variant)
+		}
+		sts = append(sts, st)
+	}
+	sort.SliceStable(sts, func(i, j int) bool {
+		if sts[i].Package != sts[j].Package {
+			return sts[i].Package < sts[j].Package
+		}
+		return sts[i].Name < sts[j].Name
+	})
+	return sts, nil
+}
+
+// findPackagesDefs returns the definitions of the sum types declared in the
+// given packages, either in their source or in the configuration file, or the
+// errors for any invalid declarations, prefixed with their positions.
+func findPackagesDefs(pkgs []*packages.Package) ([]sumTypeDef, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	var defs []sumTypeDef
+	var errs []error
+	for _, pkg := range pkgs {
+		decls := findSumTypeDecls(pkg.Types, pkg.Syntax)
+		decls = append(decls, cfg.sumTypeDecls(pkg.Types, pkg.Syntax, decls)...)
+		pkgDefs, pkgErrs := findSumTypeDefs(decls)
+		defs = append(defs, pkgDefs...)
+		if len(pkgErrs) > 0 {
+			errs = append(errs, joinDiagnostics(pkg.Fset, pkgErrs))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return defs, nil
+}
+
+// countSwitches returns the number of switch statements in the given
+// packages over each of the given sum types, keyed by the sum type's
+// definition.
+func countSwitches(pkgs []*packages.Package, defs []sumTypeDef) map[*types.TypeName]int {
+	idx := newDefIndex(defs)
+	counts := make(map[*types.TypeName]int)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				var def *sumTypeDef
+				switch n := n.(type) {
+				case *ast.TypeSwitchStmt:
+					def = idx.find(pkg.TypesInfo.TypeOf(findTypeAssertExpr(n)))
+				case *ast.SwitchStmt:
+					if n.Tag != nil {
+						def = idx.findEnum(pkg.TypesInfo.TypeOf(n.Tag))
+					}
+				}
+				if def != nil {
+					counts[def.Obj]++
+				}
+				return true
+			})
+		}
+	}
+	return counts
+}
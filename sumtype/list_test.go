@@ -0,0 +1,156 @@
+package sumtype
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestList tests that sum types are listed with their variants, in
+// declaration order, and the number of switches over them in all of the
+// given packages.
+func TestList(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Expr
+
+type Expr interface { expr() }
+
+type Lit int
+func (Lit) expr() {}
+
+type Ident struct { Name string }
+func (*Ident) expr() {}
+
+//go-sumtype:enum Op
+
+type Op int
+
+const (
+	Add Op = iota
+	Sub
+)
+
+func Eval(x Expr) {
+	switch x.(type) {
+	case Lit, *Ident:
+	}
+}
+`,
+		"use/use.go": `
+package use
+
+import "example.com/m/ast"
+
+func F(x ast.Expr, op ast.Op) {
+	switch x.(type) {
+	default:
+	}
+	switch op {
+	case ast.Add, ast.Sub:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+
+	sts, err := List(pkgs)
+	if !assert.NoError(t, err) || !assert.Len(t, sts, 2) {
+		t.FailNow()
+	}
+
+	expr := sts[0]
+	assert.Equal(t, "example.com/m/ast", expr.Package)
+	assert.Equal(t, "Expr", expr.Name)
+	assert.False(t, expr.Enum)
+	assert.Equal(t, 4, expr.Decl.Line)
+	assert.Equal(t, 6, expr.Pos.Line)
+	assert.Equal(t, 2, expr.Switches)
+	if assert.Len(t, expr.Variants, 2) {
+		assert.Equal(t, Variant{
+			Name: "Lit", Type: "Lit", Receiver: "value", Pos: expr.Variants[0].Pos,
+		}, expr.Variants[0])
+		assert.Equal(t, Variant{
+			Name: "Ident", Type: "*Ident", Receiver: "pointer", Pos: expr.Variants[1].Pos,
+		}, expr.Variants[1])
+		assert.Equal(t, 8, expr.Variants[0].Pos.Line)
+		assert.Equal(t, 11, expr.Variants[1].Pos.Line)
+	}
+
+	op := sts[1]
+	assert.Equal(t, "Op", op.Name)
+	assert.True(t, op.Enum)
+	assert.Equal(t, 1, op.Switches)
+	if assert.Len(t, op.Variants, 2) {
+		assert.Equal(t, "Add", op.Variants[0].Type)
+		assert.Equal(t, "", op.Variants[0].Receiver)
+		assert.Equal(t, "Sub", op.Variants[1].Name)
+	}
+}
+
+// TestListErrors tests that the errors for invalid declarations are prefixed
+// with their positions.
+func TestListErrors(t *testing.T) {
+	files := map[string]string{
+		"ast/ast.go": `
+package ast
+
+//go-sumtype:decl Missing
+
+//go-sumtype:decl Lit
+
+type Lit int
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+
+	_, err := List(pkgs)
+	path := filepath.Join(tmpdir, "ast", "ast.go")
+	assert.EqualError(t, err,
+		path+":4:1: type 'Missing' is not defined\n"+
+			path+":6:1: type 'Lit' is not an interface")
+}
+
+// TestListConfig tests that sum types declared in the configuration file are
+// listed, along with the switches over them.
+func TestListConfig(t *testing.T) {
+	files := map[string]string{
+		".go-sumtype.yaml": `
+sumtypes:
+  - type: example.com/m/ast.Node
+`,
+		"ast/ast.go": `
+package ast
+
+type Node interface { node() }
+
+type Lit int
+func (Lit) node() {}
+
+func Eval(n Node) {
+	switch n.(type) {
+	case Lit:
+	}
+}
+`,
+	}
+	tmpdir, pkgs := setupModule(t, files, "./...")
+	defer teardownPackage(t, tmpdir)
+	defer chdir(t, tmpdir)()
+
+	sts, err := List(pkgs)
+	if !assert.NoError(t, err) || !assert.Len(t, sts, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Node", sts[0].Name)
+	assert.Equal(t, 2, sts[0].Decl.Line)
+	assert.Equal(t, 1, sts[0].Switches)
+	if assert.Len(t, sts[0].Variants, 1) {
+		assert.Equal(t, "Lit", sts[0].Variants[0].Name)
+	}
+}
@@ -0,0 +1,184 @@
+package sumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// noReturnFact is exported for every function annotated with
+// `//go-sumtype:noreturn` in its doc comment, so that calls to it are known
+// to never return in any package.
+type noReturnFact struct{}
+
+func (*noReturnFact) AFact() {}
+
+func (*noReturnFact) String() string {
+	return "noreturn"
+}
+
+// noReturnError corresponds to a function annotated with
+// `//go-sumtype:noreturn` that may return.
+type noReturnError struct {
+	Pos  token.Pos
+	End  token.Pos
+	Name string
+}
+
+func (e noReturnError) Error() string {
+	return fmt.Sprintf(
+		"function '%s' is annotated with go-sumtype:noreturn but may return",
+		e.Name)
+}
+
+func (e noReturnError) diagnostic() analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      e.Pos,
+		End:      e.End,
+		Category: RuleNoReturn,
+		Message:  e.Error(),
+	}
+}
+
+// intrinsicNoReturn is the set of functions, by full name, outside of the
+// package being analyzed that are known to never return.
+var intrinsicNoReturn = map[string]bool{
+	"os.Exit":        true,
+	"runtime.Goexit": true,
+	"syscall.Exit":   true,
+
+	"log.Fatal":                 true,
+	"log.Fatalf":                true,
+	"log.Fatalln":               true,
+	"log.Panic":                 true,
+	"log.Panicf":                true,
+	"log.Panicln":               true,
+	"(*log.Logger).Fatal":       true,
+	"(*log.Logger).Fatalf":      true,
+	"(*log.Logger).Fatalln":     true,
+	"(*log.Logger).Panic":       true,
+	"(*log.Logger).Panicf":      true,
+	"(*log.Logger).Panicln":     true,
+	"(*testing.common).Fatal":   true,
+	"(*testing.common).Fatalf":  true,
+	"(*testing.common).FailNow": true,
+	"(*testing.common).Skip":    true,
+	"(*testing.common).Skipf":   true,
+	"(*testing.common).SkipNow": true,
+	"(testing.TB).Fatal":        true,
+	"(testing.TB).Fatalf":       true,
+	"(testing.TB).FailNow":      true,
+	"(testing.TB).Skip":         true,
+	"(testing.TB).Skipf":        true,
+	"(testing.TB).SkipNow":      true,
+}
+
+// exportNoReturnFacts exports a fact for every function in the package being
+// analyzed that is annotated with `//go-sumtype:noreturn`. An error is
+// returned for each annotated function that may return.
+func exportNoReturnFacts(pass *analysis.Pass) []error {
+	var annotated []*ast.FuncDecl
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fdecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !isNoReturnAnnotated(fdecl.Doc) {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[fdecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			pass.ExportObjectFact(fn, new(noReturnFact))
+			annotated = append(annotated, fdecl)
+		}
+	}
+	// Only check annotated functions after every fact has been exported,
+	// since they may call one another.
+	var errs []error
+	for _, fdecl := range annotated {
+		if fdecl.Body != nil && !neverCompletes(pass, fdecl.Body.List) {
+			errs = append(errs, noReturnError{
+				Pos:  fdecl.Name.Pos(),
+				End:  fdecl.Name.End(),
+				Name: fdecl.Name.Name,
+			})
+		}
+	}
+	return errs
+}
+
+// isNoReturnAnnotated returns true if and only if the given doc comment
+// contains a `//go-sumtype:noreturn` line.
+func isNoReturnAnnotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == "//go-sumtype:noreturn" {
+			return true
+		}
+	}
+	return false
+}
+
+// neverCompletes returns true if the given list of statements never
+// completes normally. That is, every path through the statements ends in a
+// call to a function that never returns, such as panic, os.Exit, log.Fatal,
+// t.Fatal or a function annotated with `//go-sumtype:noreturn`. Paths that
+// return, break, continue or jump out of the statements complete normally.
+//
+// This is done on a best-effort basis. While there will never be any false
+// positives, there may be false negatives.
+func neverCompletes(pass *analysis.Pass, stmts []ast.Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	body := &ast.BlockStmt{
+		Lbrace: stmts[0].Pos(),
+		List:   stmts,
+		Rbrace: stmts[len(stmts)-1].End(),
+	}
+	g := cfg.New(body, func(call *ast.CallExpr) bool {
+		return mayReturn(pass, call)
+	})
+	for _, b := range g.Blocks {
+		if !b.Live || len(b.Succs) > 0 {
+			continue
+		}
+		// Every live block without successors must end in a call that
+		// never returns. Otherwise, it returns from the function, falls
+		// off the end of the statements or leaves them via a branch.
+		if len(b.Nodes) == 0 {
+			return false
+		}
+		stmt, ok := b.Nodes[len(b.Nodes)-1].(*ast.ExprStmt)
+		if !ok {
+			return false
+		}
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok || mayReturn(pass, call) {
+			return false
+		}
+	}
+	return true
+}
+
+// mayReturn returns false if the given call is known to never return.
+func mayReturn(pass *analysis.Pass, call *ast.CallExpr) bool {
+	switch callee := typeutil.Callee(pass.TypesInfo, call).(type) {
+	case *types.Builtin:
+		return callee.Name() != "panic"
+	case *types.Func:
+		if intrinsicNoReturn[callee.FullName()] {
+			return false
+		}
+		return !pass.ImportObjectFact(callee.Origin(), new(noReturnFact))
+	}
+	return true
+}
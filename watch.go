@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/BurntSushi/go-sumtype/sumtype"
+)
+
+// watchDebounce is how long to wait after a file changes before checking
+// packages again, so that a burst of changes (e.g., from a version control
+// checkout) is handled at once.
+const watchDebounce = 200 * time.Millisecond
+
+// watcher keeps the results of checking a set of packages in memory, and
+// checks packages again when their files change.
+//
+// When the files in a directory change, only the packages in that directory
+// are loaded and checked again, along with the packages importing them if
+// the variants of a sum type declared in them changed. (Adding a variant
+// can make case analysis in an importing package inexhaustive, and removing
+// one can make a case unreachable.)
+type watcher struct {
+	conf *packages.Config
+	// The records of the errors found in each package, keyed by import path.
+	records map[string][]record
+	// The import paths of the packages in each directory, keyed by
+	// directory.
+	pkgPaths map[string][]string
+	// The directories of the packages importing each package, keyed by
+	// import path.
+	importers map[string]map[string]bool
+	// The variants of the sum types declared in each package, keyed by
+	// import path and then by the name of the sum type.
+	variants map[string]map[string][]string
+}
+
+// newWatcher returns a watcher of the given packages, which were loaded with
+// the given configuration and checked with the given results.
+func newWatcher(conf *packages.Config, pkgs []*packages.Package, records []record) *watcher {
+	w := &watcher{
+		conf:      conf,
+		records:   make(map[string][]record),
+		pkgPaths:  make(map[string][]string),
+		importers: make(map[string]map[string]bool),
+		variants:  make(map[string]map[string][]string),
+	}
+	w.index(pkgs, records)
+	return w
+}
+
+// run checks packages again whenever their files change, and calls report
+// with the records of every error found after each check. It only returns
+// if the directories can no longer be watched.
+func (w *watcher) run(report func(records []record) int) error {
+	dirs, err := newDirWatcher()
+	if err != nil {
+		return err
+	}
+	for dir := range w.pkgPaths {
+		if err := dirs.add(dir); err != nil {
+			return err
+		}
+	}
+	log.Printf("watching %d directories for changes", len(w.pkgPaths))
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+	for {
+		select {
+		case ev := <-dirs.events:
+			if ev.Dir {
+				// New directories may contain new packages.
+				if err := dirs.add(ev.Path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if filepath.Ext(ev.Path) != ".go" {
+				continue
+			}
+			pending[filepath.Dir(ev.Path)] = true
+			timer = time.After(watchDebounce)
+		case err := <-dirs.errs:
+			return err
+		case <-timer:
+			var changed []string
+			for dir := range pending {
+				changed = append(changed, dir)
+			}
+			sort.Strings(changed)
+			rechecked, err := w.recheck(changed)
+			if err != nil {
+				// Keep the directories pending, so that they're
+				// checked once the error is fixed.
+				log.Print(err)
+				continue
+			}
+			pending = make(map[string]bool)
+			n := report(w.all())
+			log.Printf("checked %s: %d errors", strings.Join(rechecked, ", "), n)
+		}
+	}
+}
+
+// recheck loads and checks the packages in the given directories again,
+// along with the packages importing any sum types whose variants changed.
+// It returns the import paths of the packages that were checked.
+func (w *watcher) recheck(dirs []string) ([]string, error) {
+	pkgs, err := w.load(dirs)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, dir := range dirs {
+		changed[dir] = true
+	}
+	var changedPaths map[string]bool
+	if newVariants, err := variantsByPackage(pkgs); err == nil {
+		changedPaths = w.changedVariants(dirs, newVariants)
+	} else {
+		// The sum types can't be determined, e.g., because a declaration
+		// is invalid, so assume that all of them changed. The error is
+		// reported by the analyzer.
+		changedPaths = make(map[string]bool)
+		for _, pkg := range pkgs {
+			changedPaths[pkg.PkgPath] = true
+		}
+	}
+	var affected []string
+	for path := range changedPaths {
+		for dir := range w.importers[path] {
+			if !changed[dir] {
+				changed[dir] = true
+				affected = append(affected, dir)
+			}
+		}
+	}
+	if len(affected) > 0 {
+		sort.Strings(affected)
+		more, err := w.load(affected)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, more...)
+	}
+	records, err := check(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	for dir := range changed {
+		for _, path := range w.pkgPaths[dir] {
+			delete(w.records, path)
+			delete(w.variants, path)
+		}
+		delete(w.pkgPaths, dir)
+		for _, dirs := range w.importers {
+			delete(dirs, dir)
+		}
+	}
+	w.index(pkgs, records)
+
+	var rechecked []string
+	for dir := range changed {
+		rechecked = append(rechecked, w.pkgPaths[dir]...)
+	}
+	sort.Strings(rechecked)
+	return rechecked, nil
+}
+
+// changedVariants returns the import paths of the packages in the given
+// directories that declare a sum type whose variants differ from the given
+// ones.
+func (w *watcher) changedVariants(
+	dirs []string,
+	newVariants map[string]map[string][]string,
+) map[string]bool {
+	changed := make(map[string]bool)
+	for path, sts := range newVariants {
+		if !reflect.DeepEqual(sts, w.variants[path]) {
+			changed[path] = true
+		}
+	}
+	for _, dir := range dirs {
+		for _, path := range w.pkgPaths[dir] {
+			if _, ok := newVariants[path]; !ok && len(w.variants[path]) > 0 {
+				changed[path] = true
+			}
+		}
+	}
+	return changed
+}
+
+// load loads the packages in the given directories, skipping directories
+// that no longer exist.
+func (w *watcher) load(dirs []string) ([]*packages.Package, error) {
+	var patterns []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err == nil {
+			patterns = append(patterns, dir)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	pkgs, err := packages.Load(w.conf, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors loading %s", strings.Join(dirs, ", "))
+	}
+	return pkgs, nil
+}
+
+// index records the given packages, along with the given records of the
+// errors found in them.
+func (w *watcher) index(pkgs []*packages.Package, records []record) {
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 || isTestMain(pkg) {
+			continue
+		}
+		dir := pkgDir(pkg)
+		found := false
+		for _, path := range w.pkgPaths[dir] {
+			found = found || path == pkg.PkgPath
+		}
+		if !found {
+			w.pkgPaths[dir] = append(w.pkgPaths[dir], pkg.PkgPath)
+		}
+		for _, imp := range pkg.Imports {
+			if w.importers[imp.PkgPath] == nil {
+				w.importers[imp.PkgPath] = make(map[string]bool)
+			}
+			w.importers[imp.PkgPath][dir] = true
+		}
+	}
+	if variants, err := variantsByPackage(pkgs); err == nil {
+		for path, sts := range variants {
+			w.variants[path] = sts
+		}
+	}
+	for _, r := range records {
+		w.records[r.Package] = append(w.records[r.Package], r)
+	}
+}
+
+// all returns the records of the errors found in every package, sorted by
+// position.
+func (w *watcher) all() []record {
+	var records []record
+	for _, rs := range w.records {
+		records = append(records, rs...)
+	}
+	sortRecords(records)
+	return records
+}
+
+// variantsByPackage returns the names of the variants of each sum type
+// declared in the given packages, keyed by the import path of the package
+// and then by the name of the sum type.
+func variantsByPackage(pkgs []*packages.Package) (map[string]map[string][]string, error) {
+	// Sum types must only be listed once, so test variants of packages are
+	// skipped.
+	var unique []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath {
+			unique = append(unique, pkg)
+		}
+	}
+	sts, err := sumtype.List(unique)
+	if err != nil {
+		return nil, err
+	}
+	variants := make(map[string]map[string][]string)
+	for _, st := range sts {
+		if variants[st.Package] == nil {
+			variants[st.Package] = make(map[string][]string)
+		}
+		names := []string{}
+		for _, v := range st.Variants {
+			names = append(names, v.Name)
+		}
+		variants[st.Package][st.Name] = names
+	}
+	return variants, nil
+}
+
+// isTestMain returns true if and only if the given package is the generated
+// main package of a test binary.
+func isTestMain(pkg *packages.Package) bool {
+	return pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test")
+}
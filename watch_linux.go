@@ -0,0 +1,100 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// watchMask selects the inotify events that indicate that a directory's
+// contents changed.
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// dirWatcher watches directories for changes with inotify.
+type dirWatcher struct {
+	fd int
+	mu sync.Mutex
+	// The watched directories, keyed by watch descriptor.
+	dirs map[int]string
+	// The events received, which are only read by a single goroutine.
+	events chan watchEvent
+	errs   chan error
+}
+
+// watchEvent is a change to a file or directory in a watched directory.
+type watchEvent struct {
+	Path string
+	// Whether Path is a directory.
+	Dir bool
+}
+
+func newDirWatcher() (*dirWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	d := &dirWatcher{
+		fd:     fd,
+		dirs:   make(map[int]string),
+		events: make(chan watchEvent),
+		errs:   make(chan error, 1),
+	}
+	go d.read()
+	return d, nil
+}
+
+// add starts watching the given directory. Watching a directory that is
+// already watched has no effect.
+func (d *dirWatcher) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(d.fd, dir, watchMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	d.mu.Lock()
+	d.dirs[wd] = dir
+	d.mu.Unlock()
+	return nil
+}
+
+// read sends an event on d.events for every inotify event received, until
+// the inotify file descriptor can no longer be read.
+func (d *dirWatcher) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := syscall.Read(d.fd, buf)
+		if err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			d.errs <- os.NewSyscallError("read", err)
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				d.mu.Lock()
+				delete(d.dirs, int(ev.Wd))
+				d.mu.Unlock()
+				continue
+			}
+			name := strings.TrimRight(string(buf[start:off]), "\x00")
+			d.mu.Lock()
+			dir, ok := d.dirs[int(ev.Wd)]
+			d.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+			d.events <- watchEvent{
+				Path: filepath.Join(dir, name),
+				Dir:  ev.Mask&syscall.IN_ISDIR != 0,
+			}
+		}
+	}
+}
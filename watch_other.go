@@ -0,0 +1,27 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// dirWatcher watches directories for changes. It is only implemented on
+// Linux, with inotify.
+type dirWatcher struct {
+	events chan watchEvent
+	errs   chan error
+}
+
+// watchEvent is a change to a file or directory in a watched directory.
+type watchEvent struct {
+	Path string
+	// Whether Path is a directory.
+	Dir bool
+}
+
+func newDirWatcher() (*dirWatcher, error) {
+	return nil, errors.New("-watch is only supported on Linux")
+}
+
+func (d *dirWatcher) add(dir string) error {
+	return errors.New("-watch is only supported on Linux")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+// TestWatcherRecheck tests that the packages importing a sum type are only
+// checked again when its variants change.
+func TestWatcherRecheck(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "go-test-sumtype-watch-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	writeFile := func(name, code string) {
+		path := filepath.Join(tmpdir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("go.mod", "module example.com/m\n\ngo 1.22\n")
+	const a = `
+package a
+
+//go-sumtype:decl T
+
+type T interface{ sealed() }
+
+type X struct{}
+
+func (X) sealed() {}
+`
+	writeFile("a/a.go", a)
+	writeFile("b/b.go", `
+package b
+
+import "example.com/m/a"
+
+func F(t a.T) {
+	switch t.(type) {
+	case a.X:
+	}
+}
+`)
+
+	conf := &packages.Config{Mode: packages.LoadAllSyntax, Dir: tmpdir, Tests: true}
+	pkgs, err := packages.Load(conf, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := check(pkgs)
+	if !assert.NoError(t, err) || !assert.Empty(t, records) {
+		t.FailNow()
+	}
+	w := newWatcher(conf, pkgs, records)
+	dirA := filepath.Join(tmpdir, "a")
+
+	// A change that doesn't affect the variants of T only checks a again.
+	writeFile("a/a.go", a+"\nfunc Unrelated() {}\n")
+	rechecked, err := w.recheck([]string{dirA})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/m/a"}, rechecked)
+		assert.Empty(t, w.all())
+	}
+
+	// Adding a variant checks b again, whose type switch is now missing it.
+	writeFile("a/a.go", a+"\ntype Y struct{}\n\nfunc (Y) sealed() {}\n")
+	rechecked, err = w.recheck([]string{dirA})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/m/a", "example.com/m/b"}, rechecked)
+		records := w.all()
+		if assert.Len(t, records, 1) {
+			assert.Equal(t, "example.com/m/b", records[0].Package)
+			assert.Equal(t, "F", records[0].Function)
+			if assert.Len(t, records[0].Missing, 1) {
+				assert.Equal(t, "Y", records[0].Missing[0].Name)
+			}
+		}
+	}
+}